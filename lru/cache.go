@@ -5,99 +5,1484 @@
 // reducing the memory and CPU overhead of renewing entries
 // (instead of constantly running a separate worker to check and update expired values,
 // even when it may not be necessary).
+//
+// Expired entries are removed lazily on access. For caches holding large values a background
+// janitor removing expired entries periodically can be enabled with WithCleanupInterval.
 package lru
 
 import (
 	"container/list"
+	"context"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const defaultSize int = 128
+// DefaultCapacity is the capacity of caches created without WithCapacity
+const DefaultCapacity int = 128
+
+// MaxCostLimit is the maximum value of WithMaxCost, total cost stays within int64
+// when an entry with cost up to the limit is added to entries with total cost up to the limit
+const MaxCostLimit int64 = math.MaxInt64 / 2
 
-// Cache is a generic, thread-safe cache implementing LRU eviction and TTL-based invalidation
+// maxSizeHint limits the items map pre-sizing, so huge capacities (e.g. unlimited by WithMaxCost) don't over-allocate
+const maxSizeHint = 1 << 16
+
+// Cache is a generic, thread-safe cache implementing LRU eviction and TTL-based invalidation,
+// LFU eviction can be enabled with WithPolicy, custom victim selection with WithEvictionPolicy.
+//
+// Reads are served under a read lock: hit entries are promoted to the front lazily,
+// pending promotions are applied before any operation taking the write lock, e.g. before eviction.
 type Cache[K comparable, V any] struct {
 	items     map[K]*list.Element
 	evictList *list.List
 	capacity  int
-	lock      sync.Mutex
+	lock      sync.RWMutex
+	// sizeHint is the items map pre-size used on creation and by Clear
+	sizeHint int
+	// options the cache is created with, they're used by Clone
+	options cacheOptions
+
+	// expiry queues elements of entries with expiry time by it, so eviction finds expired ones quickly
+	expiry expiryQueue[K, V]
+
+	// promotions buffers elements hit under the read lock, they are moved to front under the write lock
+	promotions promotions
+
+	policy Policy
+	// groupHeads holds the front element of each group of entries, see group
+	groupHeads map[group]*list.Element
+	// sample holds all elements if PolicyRandom is used, so a random one is chosen in O(1)
+	sample []*list.Element
+	// prioritized is set once an entry with non-zero priority is added
+	prioritized bool
+
+	// admission is the frequency sketch gating new entries when the cache is full, nil if disabled
+	admission *sketch
+	hash      func(K) uint64
+
+	// maxCost limits the total cost of entries, zero value means cost isn't limited
+	maxCost   int64
+	totalCost int64
+	// sizer estimates entries' sizes summed in Stats EstimatedBytes, nil if WithSizer isn't used
+	sizer func(k K, v V) int64
+	// maxEntrySize limits entries' sizes estimated by sizer, zero value means sizes aren't limited
+	maxEntrySize int64
+
+	// now returns the current time, time.Now unless set by WithClock
+	now func() time.Time
+
+	// ttl holds time.Duration defining the time-to-live for entries set without own TTL,
+	// zero value means such entries never expire, it's atomic, because it's changed by SetTTL
+	ttl atomic.Int64
+	// slidingTTL enables refreshing entry's expiry time on each Get hit
+	slidingTTL bool
+	// maxAge limits entries' lifetime since they're set regardless of refreshes, zero means no limit
+	maxAge time.Duration
+	// staleGrace is the period expired entries are kept for GetStale
+	staleGrace time.Duration
+	// noRecencyUpdate disables moving entries to the front on Get hits
+	noRecencyUpdate bool
+	// fixedExpiry keeps entries' expiry time when their values are set again
+	fixedExpiry bool
+	// secondChance marks entries referenced on Get hits instead of moving them to the front
+	secondChance bool
+	// promotionWindow is the min period between moves of an entry to the front on Get hits
+	promotionWindow time.Duration
+	// evictionPolicy selects victims instead of the evict list order, nil if WithEvictionPolicy isn't used
+	evictionPolicy EvictionPolicy[K, V]
+	// jitter randomizes expiry times of set entries, nil if WithTTLJitter isn't used
+	jitter *jitter
+
+	// onEvict is invoked for evicted entries after the lock is released,
+	// so it may safely call back into the cache
+	onEvict func(k K, v V, reason EvictReason)
+	// evicted holds entries evicted under the lock, which are passed to onEvict on unlock
+	evicted []evicted[K, V]
+	// onFull is invoked by unlock once the cache is filled, full is reset when the cache drops below capacity
+	onFull func()
+	full   bool
+	filled bool
+	// onError receives values recovered from panics of user callbacks
+	onError func(r any)
+	// events is created by Events, entries removed later are sent to it
+	events chan Event[K, V]
+
+	stats   stats
+	metrics MetricRecorder
+
+	// flights deduplicates concurrent GetOrCompute calls of the same key
+	flights flightGroup[K, V]
+	// loader loads missing keys' values for Load, nil if WithLoader isn't used
+	loader func(k K) (V, error)
+	// refresh reloads hit entries expiring within refreshThreshold, nil if WithRefreshAhead isn't used,
+	// refreshes deduplicates concurrent refreshes of the same key
+	refresh          func(k K) (V, error)
+	refreshThreshold time.Duration
+	refreshes        flightGroup[K, V]
+	// onExpire is invoked asynchronously for expired entries found by lookups, nil if WithOnExpire isn't used
+	onExpire func(k K)
+	// encodeValue and decodeValue convert persisted values, nil if WithValueEncoder and WithValueDecoder aren't used
+	encodeValue func(v V) ([]byte, error)
+	decodeValue func(data []byte) (V, error)
 
-	// ttl defines the time-to-live duration for cache entries, zero value means TTL is not used
-	ttl time.Duration
+	// cleanupBatchSize is the number of entries removed by the janitor per lock acquisition
+	cleanupBatchSize int
+	// stop and done are used to stop the janitor, both are nil if the janitor isn't started
+	stop   chan struct{}
+	done   chan struct{}
+	closed atomic.Bool
+	// background tracks refresh and WithOnExpire goroutines, so Close waits for them
+	background sync.WaitGroup
 }
 
+type evicted[K comparable, V any] struct {
+	cached[K, V]
+	reason EvictReason
+}
+
+// New creates a cache configured by the options, invalid option values are ignored unless WithStrictValidation is used.
+// ErrInvalidOption is returned if options conflict with each other (e.g. different policies provided)
+// or if callbacks and hash functions don't match the cache types.
+// A janitor without the cache TTL is allowed, because entries may have their own TTL set by SetWithTTL.
 func New[K comparable, V any](opts ...Option) (*Cache[K, V], error) {
-	var o cacheOptions
-	for _, opt := range opts {
-		if opt == nil {
-			continue
+	return newCache[K, V](applyOptions(opts))
+}
+
+// NewFIFO creates a cache like New with PolicyFIFO, so entries are evicted in order they're first set
+func NewFIFO[K comparable, V any](opts ...Option) (*Cache[K, V], error) {
+	// clipped, so the caller's slice isn't overwritten
+	return New[K, V](append(slices.Clip(opts), WithPolicy(PolicyFIFO))...)
+}
+
+// Entry is a key-value pair set by NewWithEntries and SetMany, non-positive TTL means the cache TTL is used
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+	TTL   time.Duration
+}
+
+// NewWithEntries creates a cache like New and sets the entries in slice order, so the last entry
+// is the most recently used one and entries exceeding capacity are evicted like by Set
+func NewWithEntries[K comparable, V any](entries []Entry[K, V], opts ...Option) (*Cache[K, V], error) {
+	c, err := New[K, V](opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetMany(entries)
+	return c, nil
+}
+
+func newCache[K comparable, V any](o cacheOptions) (*Cache[K, V], error) {
+	if o.err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOption, o.err)
+	}
+	if o.strict && o.invalidErr != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOption, o.invalidErr)
+	}
+
+	onEvict, err := typedOption[func(K, V, EvictReason)](o.onEvict, "on evict callback")
+	if err != nil {
+		return nil, err
+	}
+	loader, err := typedOption[func(K) (V, error)](o.loader, "loader")
+	if err != nil {
+		return nil, err
+	}
+	sizer, err := typedOption[func(K, V) int64](o.sizer, "sizer")
+	if err != nil {
+		return nil, err
+	}
+	onExpire, err := typedOption[func(K)](o.onExpire, "on expire callback")
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := typedOption[func(K) (V, error)](o.refresh, "refresh function")
+	if err != nil {
+		return nil, err
+	}
+	encodeValue, err := typedOption[func(V) ([]byte, error)](o.encodeValue, "value encoder")
+	if err != nil {
+		return nil, err
+	}
+	decodeValue, err := typedOption[func([]byte) (V, error)](o.decodeValue, "value decoder")
+	if err != nil {
+		return nil, err
+	}
+	newEvictionPolicy, err := typedOption[func() EvictionPolicy[K, V]](o.newEvictionPolicy, "eviction policy constructor")
+	if err != nil {
+		return nil, err
+	}
+	hash, err := typedOption[func(K) uint64](o.hasher, "hasher")
+	if err != nil {
+		return nil, err
+	}
+	if hash == nil {
+		hash = defaultHasher[K](maphash.MakeSeed())
+	}
+
+	var evictionPolicy EvictionPolicy[K, V]
+	if newEvictionPolicy != nil {
+		if evictionPolicy = newEvictionPolicy(); evictionPolicy == nil {
+			return nil, fmt.Errorf("%w: eviction policy constructor returned nil", ErrInvalidOption)
 		}
-		opt(&o)
+		evictionPolicy = safePolicy[K, V]{policy: evictionPolicy, onError: o.onError}
 	}
-	if o.capacity <= 0 {
-		o.capacity = defaultSize
+
+	// filling the cache to capacity doesn't grow the map then
+	sizeHint := min(o.capacity, maxSizeHint)
+	if o.initialCapacity > 0 {
+		sizeHint = min(o.initialCapacity, o.capacity)
+	}
+
+	var j *jitter
+	if o.ttlJitter > 0 {
+		j = newJitter(o.ttlJitter)
 	}
 
-	return &Cache[K, V]{
-		items:     make(map[K]*list.Element),
-		evictList: list.New(),
-		capacity:  o.capacity,
-		ttl:       o.ttl,
-	}, nil
+	c := &Cache[K, V]{
+		items:      make(map[K]*list.Element, sizeHint),
+		sizeHint:   sizeHint,
+		options:    o,
+		evictList:  list.New(),
+		capacity:   o.capacity,
+		policy:     o.policy,
+		groupHeads: make(map[group]*list.Element),
+		hash:       hash,
+		maxCost:    o.maxCost,
+		sizer:      sizer,
+		now:        o.now,
+		slidingTTL: o.slidingTTL,
+		maxAge:     o.maxAge,
+		staleGrace: o.staleGrace,
+
+		maxEntrySize: o.maxEntrySize,
+
+		noRecencyUpdate: o.noRecencyUpdate || o.policy == PolicyRandom || o.policy == PolicyFIFO,
+		secondChance:    o.secondChance,
+		fixedExpiry:     o.fixedExpiry,
+		promotionWindow: o.promotionWindow,
+		jitter:          j,
+		evictionPolicy:  evictionPolicy,
+		onFull:          o.onFull,
+		metrics:         o.metrics,
+		onError:         o.onError,
+		onEvict:         onEvict,
+		loader:          loader,
+
+		refresh:          refresh,
+		refreshThreshold: o.refreshThreshold,
+		onExpire:         onExpire,
+		encodeValue:      encodeValue,
+		decodeValue:      decodeValue,
+	}
+	c.ttl.Store(int64(o.ttl))
+	if _, ok := o.metrics.(noopRecorder); !ok {
+		c.metrics = safeRecorder{recorder: o.metrics, onError: o.onError}
+	}
+	if o.admission {
+		c.admission = newSketch(o.capacity)
+	}
+	if o.cleanupInterval > 0 {
+		batchSize := o.cleanupBatchSize
+		if batchSize <= 0 {
+			batchSize = defaultCleanupBatchSize
+		}
+		c.startJanitor(o.cleanupInterval, batchSize)
+	}
+
+	return c, nil
 }
 
-// Set sets a value for specified key to the cache
+// writeLock acquires the write lock and applies pending promotions, must be released by unlock
+func (c *Cache[K, V]) writeLock() {
+	c.lock.Lock()
+	c.applyPromotions()
+}
+
+// unlock releases the write lock and then invokes onEvict for entries evicted while it was held
+func (c *Cache[K, V]) unlock() {
+	evicted := c.evicted
+	c.evicted = nil
+	if c.full && c.evictList.Len() < c.capacity {
+		c.full = false
+	}
+	filled := c.filled
+	c.filled = false
+	size := c.evictList.Len()
+	c.lock.Unlock()
+
+	c.metrics.ObserveSize(size)
+
+	for _, val := range evicted {
+		c.callback(func() { c.onEvict(val.key, val.value, val.reason) })
+	}
+	if filled {
+		c.callback(c.onFull)
+	}
+}
+
+// callback invokes the user callback recovering its panic, so it can't break the caller like the janitor,
+// the recovered value is passed to onError if it's set
+func (c *Cache[K, V]) callback(fn func()) {
+	defer func() {
+		if r := recover(); r != nil && c.onError != nil {
+			c.onError(r)
+		}
+	}()
+	fn()
+}
+
+// valueOf returns the entry stored in the element, an element holding another type is treated as
+// a cached miss instead of panicking, so it's never served and is removed like other entries.
+// The returned miss isn't stored in the element, so such elements aren't queued or sampled
+func (c *Cache[K, V]) valueOf(e *list.Element) *cached[K, V] {
+	if val, ok := e.Value.(*cached[K, V]); ok && val != nil {
+		return val
+	}
+	return &cached[K, V]{negative: true}
+}
+
+// removeElement removes the entry from the cache, lock must be held
+func (c *Cache[K, V]) removeElement(e *list.Element, reason EvictReason) {
+	c.unlinkEntry(e)
+
+	val := c.valueOf(e)
+	c.unschedule(val)
+	if c.items[val.key] == e {
+		delete(c.items, val.key)
+	}
+	c.totalCost -= val.cost
+	c.stats.bytes.Add(-val.size)
+
+	switch reason {
+	case ReasonCapacity:
+		c.stats.evictions.Add(1)
+		c.metrics.IncEviction()
+	case ReasonExpired:
+		c.stats.expirations.Add(1)
+	}
+
+	if c.onEvict != nil {
+		c.evicted = append(c.evicted, evicted[K, V]{cached: *val, reason: reason})
+	}
+	c.emit(val, reason)
+}
+
+// Set sets a value for specified key to the cache, the expiry time of a presented key's entry is reset
+// by the TTL unless WithFixedExpiry is used
 func (c *Cache[K, V]) Set(k K, v V) {
-	expires := time.Now().Add(c.ttl)
+	c.set(k, v, c.defaultTTL())
+}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
+// Put sets a value for specified key to the cache like Set and returns the key of the entry evicted
+// to free space for it, evicted = false if no entry was evicted. If the cost limit
+// evicts several entries, the first one's key is returned
+func (c *Cache[K, V]) Put(k K, v V) (evictedKey K, evicted bool) {
+	now := c.now()
 
-	e, ok := c.items[k]
+	c.writeLock()
+	defer c.unlock()
+
+	if val := c.store(c.makeCached(k, v, now, c.defaultTTL())); val != nil {
+		return val.key, true
+	}
+	return evictedKey, false
+}
+
+// TrySet sets a value for specified key to the cache like Set, ErrEntryTooLarge is returned
+// if the entry's size exceeds WithMaxEntrySize, ErrClosed is returned for closed cache
+func (c *Cache[K, V]) TrySet(k K, v V) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+
+	now := c.now()
+	val := c.makeCached(k, v, now, c.defaultTTL())
+
+	c.writeLock()
+	defer c.unlock()
+
+	c.store(val)
+	if c.oversized(val) {
+		return ErrEntryTooLarge
+	}
+	return nil
+}
+
+// SetTTL changes the cache TTL used for entries set later, existing entries keep their expiry time,
+// zero TTL means such entries never expire, negative values are ignored like by WithTTL
+func (c *Cache[K, V]) SetTTL(ttl time.Duration) {
+	if ttl >= 0 {
+		c.ttl.Store(int64(ttl))
+	}
+}
+
+// defaultTTL returns the cache TTL
+func (c *Cache[K, V]) defaultTTL() time.Duration {
+	return time.Duration(c.ttl.Load())
+}
+
+// TTL returns the cache TTL used for entries set without own TTL, enabled = false if such entries never expire,
+// it may be changed by SetTTL. Entries set by SetWithTTL expire by their own TTL regardless of it
+func (c *Cache[K, V]) TTL() (ttl time.Duration, enabled bool) {
+	ttl = c.defaultTTL()
+	return ttl, ttl != 0
+}
+
+// SetWithTTL sets a value for specified key to the cache with its own TTL overriding the cache one,
+// zero TTL means the entry never expires, negative TTL is treated as zero
+func (c *Cache[K, V]) SetWithTTL(k K, v V, ttl time.Duration) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	c.set(k, v, ttl)
+}
+
+// SetWithCost sets a value for specified key to the cache with its cost counted against WithMaxCost limit,
+// entries with cost exceeding the limit aren't stored and the key's old entry is evicted,
+// negative cost is treated as zero
+func (c *Cache[K, V]) SetWithCost(k K, v V, cost int64) {
+	if cost < 0 {
+		cost = 0
+	}
+
+	val := c.makeCached(k, v, c.now(), c.defaultTTL())
+	val.cost = cost
+
+	c.writeLock()
+	defer c.unlock()
+
+	c.store(val)
+}
+
+// SetWithPriority sets a value for specified key to the cache with the priority, entries of
+// the lowest priority band are evicted first even if they're used more recently than higher priority ones.
+// Set resets the priority to zero. Priority doesn't affect TTL, expired entries are evicted regardless of it
+func (c *Cache[K, V]) SetWithPriority(k K, v V, priority int) {
+	val := c.makeCached(k, v, c.now(), c.defaultTTL())
+	val.priority = priority
+
+	c.writeLock()
+	defer c.unlock()
+
+	c.store(val)
+}
+
+func (c *Cache[K, V]) set(k K, v V, ttl time.Duration) {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	c.store(c.makeCached(k, v, now, ttl))
+}
+
+// makeCached creates an entry to set applying the cache TTL jitter
+func (c *Cache[K, V]) makeCached(k K, v V, now time.Time, ttl time.Duration) *cached[K, V] {
+	val := newCached(k, v, now, ttl)
+	if c.jitter != nil && ttl > 0 {
+		val.expiredAt = val.expiredAt.Add(c.jitter.offset(ttl))
+	}
+	val.expiredAt = c.capAge(val, val.expiredAt)
+	return val
+}
+
+// refreshedExpiry returns the entry's expiry time refreshed by its TTL, e.g. by sliding TTL
+func (c *Cache[K, V]) refreshedExpiry(val *cached[K, V], now time.Time) time.Time {
+	return c.capAge(val, expiration(now, val.ttl))
+}
+
+// capAge limits the entry's expiry time by WithMaxAge counted from its creation
+func (c *Cache[K, V]) capAge(val *cached[K, V], expiredAt time.Time) time.Time {
+	if c.maxAge <= 0 {
+		return expiredAt
+	}
+	if deadline := val.createdAt.Add(c.maxAge); expiredAt.IsZero() || deadline.Before(expiredAt) {
+		return deadline
+	}
+	return expiredAt
+}
+
+// store sets the entry and moves it to the front evicting the LRU entries if needed, lock must be held,
+// it's a no-op for closed cache. Returns the first entry evicted to free space, nil if there's no one
+func (c *Cache[K, V]) store(val *cached[K, V]) (first *cached[K, V]) {
+	if c.closed.Load() {
+		return nil
+	}
+
+	e, ok := c.items[val.key]
+	val.size = c.sizeOf(val)
+	if c.maxCost > 0 && val.cost > c.maxCost || c.oversized(val) {
+		// the entry can't fit at all, the old value mustn't be served instead of the new one
+		if ok {
+			first = c.valueOf(e)
+			c.removeElement(e, ReasonCapacity)
+		}
+		return first
+	}
+
+	now := c.now()
 	if ok {
-		e.Value = cached[K, V]{
-			key:       k,
-			value:     v,
-			expiredAt: expires,
+		old := c.valueOf(e)
+		c.totalCost += val.cost - old.cost
+		c.stats.bytes.Add(val.size - old.size)
+		val.frequency = old.frequency
+		val.pinned = old.pinned
+		val.samplePos = old.samplePos
+		if c.fixedExpiry && !old.expired(now) {
+			val.expiredAt, val.createdAt = old.expiredAt, old.createdAt
 		}
-		c.evictList.MoveToFront(e)
-		return
+		c.unschedule(old)
+		e.Value = val
+		c.schedule(e)
+		c.access(e, old.group())
+	} else {
+		if c.admission != nil {
+			c.admission.increment(c.hash(val.key))
+		}
+
+		if c.evictList.Len() >= c.capacity {
+			// the cache grows beyond capacity if all entries are pinned
+			if victim, reason := c.victim(now, nil); victim != nil {
+				if !c.admit(val.key, victim, reason) {
+					return nil
+				}
+				first = c.valueOf(victim)
+				c.removeElement(victim, reason)
+				if reason == ReasonCapacity && c.onFull != nil && !c.full {
+					c.full, c.filled = true, true
+				}
+			}
+		}
+
+		c.totalCost += val.cost
+		c.stats.bytes.Add(val.size)
+		e := c.pushEntry(val)
+		c.items[val.key] = e
+		c.schedule(e)
+		c.added(e)
 	}
 
-	if c.evictList.Len() >= c.capacity {
-		if last := c.evictList.Back(); last != nil {
-			c.evictList.Remove(last)
+	// the stored entry fits alone, so it's kept at least, it isn't a victim even if it's the LFU or random one
+	stored := c.items[val.key]
+	for c.maxCost > 0 && c.totalCost > c.maxCost && c.evictList.Len() > 1 {
+		evicted := c.evict(now, stored)
+		if evicted == nil {
+			break
+		}
+		if first == nil {
+			first = evicted
+		}
+	}
+	return first
+}
 
-			val := last.Value.(cached[K, V])
-			delete(c.items, val.key)
+// oversized reports whether the entry's size exceeds WithMaxEntrySize, val.size must be set
+func (c *Cache[K, V]) oversized(val *cached[K, V]) bool {
+	return c.maxEntrySize > 0 && val.size > c.maxEntrySize
+}
+
+// evict removes a single entry except keep to free space and returns it, nil if there's no entry to evict,
+// lock must be held
+func (c *Cache[K, V]) evict(now time.Time, keep *list.Element) *cached[K, V] {
+	e, reason := c.victim(now, keep)
+	if e == nil {
+		return nil
+	}
+	val := c.valueOf(e)
+	c.removeElement(e, reason)
+	return val
+}
+
+// victim returns an entry to evict, an expired entry is preferred wherever it's in the evict list,
+// so live entries aren't evicted while expired ones still occupy the cache, the first expired is taken.
+// Then the custom policy's or PolicyRandom victim is taken, pinned entries are skipped unless expired,
+// nil is returned if all entries are pinned. WithSecondChance referenced entries are moved to the front
+// clearing their mark instead. The keep element, e.g. the one just stored, is never returned, lock must be held
+func (c *Cache[K, V]) victim(now time.Time, keep *list.Element) (*list.Element, EvictReason) {
+	if e := c.nextExpired(now); e != nil && e != keep {
+		return e, ReasonExpired
+	}
+	if e := c.policyVictim(); e != nil && e != keep {
+		return e, ReasonCapacity
+	}
+	if c.policy == PolicyRandom {
+		if e := c.randomVictim(keep); e != nil {
+			return e, ReasonCapacity
 		}
 	}
 
-	val := cached[K, V]{
-		key:       k,
-		value:     v,
-		expiredAt: expires,
+	for e := c.evictList.Back(); e != nil; {
+		prev := e.Prev()
+		switch val := c.valueOf(e); {
+		case e == keep, val.pinned:
+		case c.secondChance && val.unreference():
+			// moved entries are reached again at the front, so unreferenced one is found anyway
+			c.touch(e)
+		default:
+			return e, ReasonCapacity
+		}
+		e = prev
 	}
-	e = c.evictList.PushFront(val)
-	c.items[k] = e
+	return nil, ReasonCapacity
 }
 
 // Get looks up a key's value from the cache, presented = false if value expired or wasn't provided
 func (c *Cache[K, V]) Get(k K) (value V, presented bool) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	value, _, state := c.get(k)
+	return value, state == StateHit
+}
+
+// GetOrDefault looks up a key's value from the cache like Get, def is returned if value expired or wasn't provided
+func (c *Cache[K, V]) GetOrDefault(k K, def V) V {
+	if v, ok := c.Get(k); ok {
+		return v
+	}
+	return def
+}
+
+// GetWithExpiry looks up a key's value from the cache like Get and returns its expiry time,
+// zero expiresAt means the entry never expires
+func (c *Cache[K, V]) GetWithExpiry(k K) (value V, expiresAt time.Time, ok bool) {
+	value, expiresAt, state := c.get(k)
+	return value, expiresAt, state == StateHit
+}
+
+// get looks up a key's value and expiry time from the cache starting its refresh ahead on hit if needed
+func (c *Cache[K, V]) get(k K) (value V, expiredAt time.Time, state State) {
+	value, expiredAt, state = c.lookup(k)
+	if state == StateHit && c.refresh != nil {
+		c.refreshAhead(k, expiredAt)
+	}
+	return value, expiredAt, state
+}
+
+// lookup looks up a key's value and expiry time from the cache, they're copied under the lock,
+// so entries may be modified in place by writers. Negative entries are returned with StateMiss
+func (c *Cache[K, V]) lookup(k K) (value V, expiredAt time.Time, state State) {
+	if c.admission != nil {
+		c.admission.increment(c.hash(k))
+	}
+	if c.slidingTTL {
+		return c.getSliding(k)
+	}
+
+	c.lock.RLock()
 
 	e, ok := c.items[k]
 	if !ok {
+		c.lock.RUnlock()
+		c.miss()
+		return value, expiredAt, StateUnknown
+	}
+	val := c.valueOf(e)
+
+	// reading the clock is skipped for hits of entries without expiry time unless it's needed for throttling
+	var now time.Time
+	if !val.expiredAt.IsZero() || c.promotionWindow > 0 {
+		now = c.now()
+	}
+	if val.expired(now) {
+		c.notifyExpired(val)
+		reclaim := c.reclaimable(val, now)
+		c.lock.RUnlock()
+		c.miss()
+		if reclaim {
+			c.removeExpired(k, e)
+		}
+		return value, expiredAt, StateUnknown
+	}
+	if val.negative {
+		c.lock.RUnlock()
+		c.miss()
+		return value, expiredAt, StateMiss
+	}
+
+	value, expiredAt = val.value, val.expiredAt
+	buffered := true
+	switch {
+	case c.secondChance:
+		val.reference()
+	case !c.noRecencyUpdate && c.promotable(val, now):
+		buffered = c.promote(e, val)
+	}
+	c.lock.RUnlock()
+
+	c.hit()
+	if !buffered {
+		c.applyFullPromotion(e)
+	}
+	return value, expiredAt, StateHit
+}
+
+// getSliding looks up a key's entry from the cache refreshing its expiry time on hit
+func (c *Cache[K, V]) getSliding(k K) (value V, expiredAt time.Time, state State) {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	val, state := c.getLocked(k, now)
+	if state != StateHit {
+		return value, expiredAt, state
+	}
+	return val.value, val.expiredAt, state
+}
+
+// getLocked looks up a key's entry from the cache moving it to the front on hit, write lock must be held
+func (c *Cache[K, V]) getLocked(k K, now time.Time) (*cached[K, V], State) {
+	e, ok := c.items[k]
+	if !ok {
+		c.miss()
+		return nil, StateUnknown
+	}
+	val := c.valueOf(e)
+
+	if val.expired(now) {
+		c.miss()
+		c.notifyExpired(val)
+		if c.reclaimable(val, now) {
+			c.removeElement(e, ReasonExpired)
+		}
+		return nil, StateUnknown
+	}
+	if val.negative {
+		c.miss()
+		return val, StateMiss
+	}
+
+	c.hit()
+	if c.slidingTTL {
+		val.expiredAt = c.refreshedExpiry(val, now)
+		c.schedule(e)
+	}
+	c.hitLocked(e, val, now)
+	return val, StateHit
+}
+
+// hitLocked registers the entry hit moving it to the front or marking it referenced by WithSecondChance,
+// write lock must be held
+func (c *Cache[K, V]) hitLocked(e *list.Element, val *cached[K, V], now time.Time) {
+	switch {
+	case c.secondChance:
+		val.reference()
+	case !c.noRecencyUpdate && c.promotable(val, now):
+		c.touch(e)
+	}
+}
+
+// promotable reports whether the hit entry should be moved to the front, entries are moved at most once
+// per WithPromotionThrottle window, concurrent hits under the read lock promote the entry once
+func (c *Cache[K, V]) promotable(val *cached[K, V], now time.Time) bool {
+	if c.promotionWindow <= 0 {
+		return true
+	}
+	promotedAt := atomic.LoadInt64(&val.promotedAt)
+	if now.UnixNano()-promotedAt < int64(c.promotionWindow) {
+		return false
+	}
+	return atomic.CompareAndSwapInt64(&val.promotedAt, promotedAt, now.UnixNano())
+}
+
+// Delete removes the key's entry from the cache, returns false if key wasn't presented
+func (c *Cache[K, V]) Delete(k K) bool {
+	c.writeLock()
+	defer c.unlock()
+
+	e, ok := c.items[k]
+	if !ok {
+		return false
+	}
+
+	c.removeElement(e, ReasonDeleted)
+	return true
+}
+
+// GetAndDelete looks up a key's value and removes its entry from the cache at once, so the value
+// is returned to a single caller only, presented = false if value expired or wasn't provided
+func (c *Cache[K, V]) GetAndDelete(k K) (value V, presented bool) {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	e, val, ok := c.liveElement(k, now)
+	if !ok {
+		c.miss()
 		return
 	}
-	val := e.Value.(cached[K, V])
 
-	// ttl zero value means TTL is not used
-	if c.ttl != 0 && val.expired(time.Now()) {
+	c.hit()
+	c.removeElement(e, ReasonDeleted)
+	return val.value, true
+}
+
+// RemoveFunc removes live entries for which pred returns true with ReasonDeleted and returns their number.
+// pred is called under the lock for the whole pass, so it mustn't call back into the cache
+func (c *Cache[K, V]) RemoveFunc(pred func(k K, v V) bool) int {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	removed := 0
+	for e := c.evictList.Back(); e != nil; {
+		prev := e.Prev()
+		if val := c.valueOf(e); !val.negative && !val.expired(now) && pred(val.key, val.value) {
+			c.removeElement(e, ReasonDeleted)
+			removed++
+		}
+		e = prev
+	}
+	return removed
+}
+
+// Len returns the number of entries in the cache, expired but not yet evicted entries aren't counted
+func (c *Cache[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	now := c.now()
+	n := 0
+	for e := c.evictList.Front(); e != nil; e = e.Next() {
+		val := c.valueOf(e)
+		if !val.negative && !val.expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// Clear removes all entries from the cache with ReasonDeleted, capacity and TTL settings are preserved
+func (c *Cache[K, V]) Clear() {
+	c.writeLock()
+	defer c.unlock()
+
+	c.clearLocked()
+}
+
+// Drain removes all entries from the cache like Clear and returns non-expired ones under a single
+// lock acquisition, e.g. to flush them to a persistent store on shutdown, cached misses are skipped
+func (c *Cache[K, V]) Drain() map[K]V {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	m := make(map[K]V, c.evictList.Len())
+	for e := c.evictList.Front(); e != nil; e = e.Next() {
+		if val := c.valueOf(e); !val.negative && !val.expired(now) {
+			m[val.key] = val.value
+		}
+	}
+	c.clearLocked()
+	return m
+}
+
+// clearLocked removes all entries with ReasonDeleted reporting them to onEvict and Events
+// like removeElement, lock must be held
+func (c *Cache[K, V]) clearLocked() {
+	if c.evictList.Len() == 0 {
+		return
+	}
+
+	for e := c.evictList.Front(); e != nil; e = e.Next() {
+		val := c.valueOf(e)
+		if c.evictionPolicy != nil {
+			c.evictionPolicy.OnRemove(e, val.key)
+		}
+		if c.onEvict != nil {
+			c.evicted = append(c.evicted, evicted[K, V]{cached: *val, reason: ReasonDeleted})
+		}
+		c.emit(val, ReasonDeleted)
+	}
+
+	c.items = make(map[K]*list.Element, c.sizeHint)
+	c.groupHeads = make(map[group]*list.Element)
+	c.expiry = nil
+	c.sample = nil
+	c.totalCost = 0
+	c.stats.bytes.Store(0)
+	// new list instead of Init, so MoveToFront is a no-op for elements still buffered in promotions
+	c.evictList = list.New()
+}
+
+// Peek looks up a key's value from the cache without updating LRU order,
+// presented = false if value expired or wasn't provided
+func (c *Cache[K, V]) Peek(k K) (value V, presented bool) {
+	c.lock.RLock()
+
+	e, ok := c.items[k]
+	if !ok {
+		c.lock.RUnlock()
 		return
 	}
+	val := c.valueOf(e)
 
-	c.evictList.MoveToFront(e)
+	if now := c.now(); val.expired(now) {
+		c.notifyExpired(val)
+		reclaim := c.reclaimable(val, now)
+		c.lock.RUnlock()
+		if reclaim {
+			c.removeExpired(k, e)
+		}
+		return
+	}
+
+	c.lock.RUnlock()
+	if val.negative {
+		return
+	}
 	return val.value, true
 }
+
+// Contains checks if the key is presented in the cache and not expired without updating LRU order
+func (c *Cache[K, V]) Contains(k K) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	e, ok := c.items[k]
+	if !ok {
+		return false
+	}
+	val := c.valueOf(e)
+
+	return !val.negative && !val.expired(c.now())
+}
+
+// GetOrSet returns the existing key's value if it's presented and not expired (loaded = true),
+// otherwise sets the value v and returns it (loaded = false), all under a single lock acquisition
+func (c *Cache[K, V]) GetOrSet(k K, v V) (actual V, loaded bool) {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	if val, state := c.getLocked(k, now); state == StateHit {
+		return val.value, true
+	}
+
+	c.store(c.makeCached(k, v, now, c.defaultTTL()))
+	return v, false
+}
+
+// LoadOrStore is GetOrSet named after sync.Map method, expired entries are treated as absent and replaced
+func (c *Cache[K, V]) LoadOrStore(k K, v V) (actual V, loaded bool) {
+	return c.GetOrSet(k, v)
+}
+
+// SetIfAbsent sets a value for specified key only if the key isn't presented or expired,
+// returns true if the value was stored
+func (c *Cache[K, V]) SetIfAbsent(k K, v V) bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	if _, _, ok := c.liveElement(k, now); ok {
+		return false
+	}
+
+	// the value may be rejected by admission or cost limit
+	c.store(c.makeCached(k, v, now, c.defaultTTL()))
+	_, ok := c.items[k]
+	return ok
+}
+
+// SetIfPresent sets a value for specified key only if the key is presented and not expired,
+// so deleted or evicted keys aren't set again, returns true if the value was updated
+func (c *Cache[K, V]) SetIfPresent(k K, v V) bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	if _, _, ok := c.liveElement(k, now); !ok {
+		return false
+	}
+
+	// the value may be rejected by cost limit
+	c.store(c.makeCached(k, v, now, c.defaultTTL()))
+	_, ok := c.items[k]
+	return ok
+}
+
+// SetIfChanged sets a value for specified key to the cache like Set only if it differs from the live stored one,
+// returns true if the value was set. An equal value isn't set again, so the entry's expiry time is kept,
+// but it's moved to the front like on Get hit
+func SetIfChanged[K, V comparable](c *Cache[K, V], k K, v V) bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	if e, val, ok := c.liveElement(k, now); ok && val.value == v {
+		c.hitLocked(e, val, now)
+		return false
+	}
+
+	// the value may be rejected by admission or cost limit
+	c.store(c.makeCached(k, v, now, c.defaultTTL()))
+	_, ok := c.items[k]
+	return ok
+}
+
+// SetWithVersion sets a value for specified key to the cache only if the version is greater than
+// the stored entry's one, so an older value set out of order doesn't overwrite a newer one.
+// Entries set without version have zero version, expired entries are treated as absent.
+// Returns true if the value was stored
+func (c *Cache[K, V]) SetWithVersion(k K, v V, version uint64) bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	if _, val, ok := c.liveElement(k, now); ok && val.version >= version {
+		return false
+	}
+
+	val := c.makeCached(k, v, now, c.defaultTTL())
+	val.version = version
+	// the value may be rejected by admission or cost limit
+	c.store(val)
+	_, ok := c.items[k]
+	return ok
+}
+
+// GetOrCompute returns the key's value if it's presented and not expired, otherwise computes and sets it.
+// Concurrent calls for the same missing key run compute once, other callers wait for its result.
+// On compute error nothing is set and the error is returned to all waiting callers.
+// Returns ErrClosed if the cache is closed and ErrRecursiveLoad if compute computes its own key.
+func (c *Cache[K, V]) GetOrCompute(k K, compute func() (V, error)) (V, error) {
+	if c.closed.Load() {
+		var zero V
+		return zero, ErrClosed
+	}
+	if v, ok := c.Get(k); ok {
+		return v, nil
+	}
+
+	return c.flights.do(k, func() (V, error) {
+		return c.computeAndSet(k, compute)
+	})
+}
+
+// GetOrComputeCtx is like GetOrCompute, but returns ctx.Err() if the context is done before the value
+// is computed. The computation is shared by concurrent callers, so compute receives a context
+// that isn't cancelled with the caller's one and continues after the caller stops waiting.
+func (c *Cache[K, V]) GetOrComputeCtx(ctx context.Context, k K, compute func(ctx context.Context) (V, error)) (V, error) {
+	if c.closed.Load() {
+		var zero V
+		return zero, ErrClosed
+	}
+	if v, ok := c.Get(k); ok {
+		return v, nil
+	}
+
+	computeCtx := context.WithoutCancel(ctx)
+	return c.flights.doCtx(ctx, k, func() (V, error) {
+		return c.computeAndSet(k, func() (V, error) {
+			return compute(computeCtx)
+		})
+	})
+}
+
+// Load returns the key's value if it's presented and not expired, otherwise loads it by WithLoader loader
+// and sets it like GetOrCompute, so concurrent loads of the same key are deduplicated and errors aren't cached.
+// The loader loading its own key via Load directly or via loads of other keys gets ErrRecursiveLoad.
+// Returns ErrNoLoader if the loader isn't set and ErrClosed if the cache is closed
+func (c *Cache[K, V]) Load(k K) (V, error) {
+	if c.loader == nil {
+		var zero V
+		return zero, ErrNoLoader
+	}
+	return c.GetOrCompute(k, func() (V, error) {
+		return c.loader(k)
+	})
+}
+
+// computeAndSet computes and sets the key's value unless it's already presented
+func (c *Cache[K, V]) computeAndSet(k K, compute func() (V, error)) (V, error) {
+	// the value could be set by a flight completed right before this one started
+	if v, ok := c.Peek(k); ok {
+		return v, nil
+	}
+
+	v, err := compute()
+	if err != nil {
+		return v, err
+	}
+	c.Set(k, v)
+	return v, nil
+}
+
+// Resize changes the cache capacity evicting the least recently used entries if they don't fit,
+// returns the number of evicted entries, non-positive capacity values are ignored
+func (c *Cache[K, V]) Resize(capacity int) int {
+	if capacity <= 0 {
+		return 0
+	}
+
+	c.writeLock()
+	defer c.unlock()
+
+	c.capacity = capacity
+
+	now := c.now()
+	n := 0
+	for c.evictList.Len() > c.capacity && c.evict(now, nil) != nil {
+		n++
+	}
+	return n
+}
+
+// Trim evicts the least recently used entries until at most n entries remain without changing capacity,
+// so the cache may be filled again, returns the number of evicted entries, negative n is treated as zero
+func (c *Cache[K, V]) Trim(n int) int {
+	n = max(n, 0)
+
+	c.writeLock()
+	defer c.unlock()
+
+	now := c.now()
+	evicted := 0
+	for c.evictList.Len() > n && c.evict(now, nil) != nil {
+		evicted++
+	}
+	return evicted
+}
+
+// SetMany sets the entries under a single lock acquisition in slice order, so the last entry
+// is the most recently used one, a key repeated in entries keeps the last value
+func (c *Cache[K, V]) SetMany(entries []Entry[K, V]) {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	for _, ent := range entries {
+		ttl := ent.TTL
+		if ttl <= 0 {
+			ttl = c.defaultTTL()
+		}
+		c.store(c.makeCached(ent.Key, ent.Value, now, ttl))
+	}
+}
+
+// GetMany looks up the keys' values under a single lock acquisition like Get,
+// the result holds only presented and not expired values
+func (c *Cache[K, V]) GetMany(keys []K) map[K]V {
+	values := make(map[K]V, len(keys))
+	c.lookupBatch(keys, func(i int, val *cached[K, V], _ time.Time) {
+		values[keys[i]] = val.value
+	})
+	return values
+}
+
+// GetMulti looks up the keys' values under a single lock acquisition like Get,
+// values and found are ordered like keys, found[i] = false if keys[i] value expired or wasn't provided
+func (c *Cache[K, V]) GetMulti(keys ...K) (values []V, found []bool) {
+	values = make([]V, len(keys))
+	found = make([]bool, len(keys))
+	c.lookupBatch(keys, func(i int, val *cached[K, V], _ time.Time) {
+		values[i], found[i] = val.value, true
+	})
+	return values, found
+}
+
+// lookupBatch looks up the keys like lookup under a single read lock acquisition calling hit with the lock held
+// for each hit entry and the lookup time. Hits are promoted through the buffer like Get ones, expired entries
+// are removed once the lock is released. Sliding TTL refreshes entries, so the write lock is taken then
+func (c *Cache[K, V]) lookupBatch(keys []K, hit func(i int, val *cached[K, V], now time.Time)) {
+	if c.admission != nil {
+		for _, k := range keys {
+			c.admission.increment(c.hash(k))
+		}
+	}
+	if c.slidingTTL {
+		now := c.now()
+
+		c.writeLock()
+		defer c.unlock()
+
+		for i, k := range keys {
+			if val, state := c.getLocked(k, now); state == StateHit {
+				hit(i, val, now)
+			}
+		}
+		return
+	}
+
+	var expired, overflowed []*list.Element
+	// the clock is read once when it's needed first like by lookup
+	var now time.Time
+	clocked := false
+	c.lock.RLock()
+	for i, k := range keys {
+		e, ok := c.items[k]
+		if !ok {
+			c.miss()
+			continue
+		}
+		val := c.valueOf(e)
+		if !clocked && (!val.expiredAt.IsZero() || c.promotionWindow > 0) {
+			now, clocked = c.now(), true
+		}
+		if val.expired(now) {
+			c.notifyExpired(val)
+			if c.reclaimable(val, now) {
+				expired = append(expired, e)
+			}
+			c.miss()
+			continue
+		}
+		if val.negative {
+			c.miss()
+			continue
+		}
+
+		hit(i, val, now)
+		c.hit()
+		switch {
+		case c.secondChance:
+			val.reference()
+		case !c.noRecencyUpdate && c.promotable(val, now) && !c.promote(e, val):
+			overflowed = append(overflowed, e)
+		}
+	}
+	c.lock.RUnlock()
+
+	if len(overflowed) > 0 {
+		c.applyFullPromotion(overflowed...)
+	}
+	for _, e := range expired {
+		c.removeExpired(c.valueOf(e).key, e)
+	}
+}
+
+// EntryStatus is a key lookup result returned by GetBatchStatus
+type EntryStatus[V any] struct {
+	Value V
+	Found bool
+	// ExpiresIn is the time left until the entry expires, zero if it never expires or isn't found
+	ExpiresIn time.Duration
+}
+
+// GetBatchStatus looks up the keys' values under a single lock acquisition like GetMulti and reports
+// the time left until their entries expire, e.g. to refresh ones close to expiry, statuses are ordered like keys
+func (c *Cache[K, V]) GetBatchStatus(keys []K) []EntryStatus[V] {
+	statuses := make([]EntryStatus[V], len(keys))
+	c.lookupBatch(keys, func(i int, val *cached[K, V], now time.Time) {
+		statuses[i] = EntryStatus[V]{Value: val.value, Found: true}
+		if !val.expiredAt.IsZero() {
+			statuses[i].ExpiresIn = val.expiredAt.Sub(now)
+		}
+	})
+	return statuses
+}
+
+// Touch refreshes the entry's expiry time by its TTL and moves it to the front without reading its value,
+// returns false if key wasn't presented or expired
+func (c *Cache[K, V]) Touch(k K) bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	e, val, ok := c.liveElement(k, now)
+	if !ok {
+		return false
+	}
+
+	val.expiredAt = c.refreshedExpiry(val, now)
+	c.schedule(e)
+	c.touch(e)
+	return true
+}
+
+// ExpireNow makes the entry expired without removing it, so it's a miss for next lookups and
+// it's removed lazily or by the janitor with ReasonExpired like entries expired by TTL.
+// Returns false if key wasn't presented or expired
+func (c *Cache[K, V]) ExpireNow(k K) bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	e, val, ok := c.liveElement(k, now)
+	if !ok {
+		return false
+	}
+
+	// entries expire strictly after expiredAt
+	val.expiredAt = now.Add(-time.Nanosecond)
+	c.schedule(e)
+	return true
+}
+
+// AdjustTTL adds delta, which may be negative, to the entry's expiry time without moving it to the front,
+// the entry expires if the new expiry time is already passed, WithMaxAge limit is applied.
+// Entries without expiry time aren't changed. Returns false if key wasn't presented or expired
+func (c *Cache[K, V]) AdjustTTL(k K, delta time.Duration) bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	e, val, ok := c.liveElement(k, now)
+	if !ok {
+		return false
+	}
+	if val.expiredAt.IsZero() {
+		return true
+	}
+
+	val.expiredAt = c.capAge(val, val.expiredAt.Add(delta))
+	c.schedule(e)
+	return true
+}
+
+// Update replaces the key's value with fn result if it's presented and not expired and moves it to the front,
+// the entry's expiry time is kept. fn is called under the lock, so it mustn't call back into the cache.
+// Returns false if key wasn't presented or expired
+func (c *Cache[K, V]) Update(k K, fn func(old V) V) bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	e, val, ok := c.liveElement(k, now)
+	if !ok {
+		return false
+	}
+
+	val.value = fn(val.value)
+	size := c.sizeOf(val)
+	c.stats.bytes.Add(size - val.size)
+	val.size = size
+	c.touch(e)
+	return true
+}
+
+// Cap returns the cache capacity
+func (c *Cache[K, V]) Cap() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.capacity
+}
+
+// Pin exempts the entry from eviction due to capacity until Unpin, pinned entries still expire by TTL.
+// If all entries are pinned, the cache grows beyond its capacity instead of evicting them.
+// The pin is kept when the key's value is set again. Returns false if key wasn't presented or expired
+func (c *Cache[K, V]) Pin(k K) bool {
+	return c.setPinned(k, true)
+}
+
+// Unpin makes the pinned entry evictable again, returns false if key wasn't presented or expired
+func (c *Cache[K, V]) Unpin(k K) bool {
+	return c.setPinned(k, false)
+}
+
+func (c *Cache[K, V]) setPinned(k K, pinned bool) bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	_, val, ok := c.liveElement(k, now)
+	if !ok {
+		return false
+	}
+
+	val.pinned = pinned
+	return true
+}
+
+// liveElement returns the key's element if it's presented, not expired and isn't a cached miss,
+// the expired entry is removed, write lock must be held
+func (c *Cache[K, V]) liveElement(k K, now time.Time) (*list.Element, *cached[K, V], bool) {
+	e, ok := c.items[k]
+	if !ok {
+		return nil, nil, false
+	}
+	val := c.valueOf(e)
+
+	if val.expired(now) {
+		if c.reclaimable(val, now) {
+			c.removeElement(e, ReasonExpired)
+		}
+		return nil, nil, false
+	}
+	if val.negative {
+		return nil, nil, false
+	}
+
+	return e, val, true
+}
+
+// PeekOldest returns the least recently used live entry, which is evicted first, without updating LRU order,
+// expired entries and cached misses are skipped, ok = false if there's no live entry
+func (c *Cache[K, V]) PeekOldest() (k K, v V, ok bool) {
+	now := c.now()
+
+	// write lock applies pending promotions, so the actual LRU order is inspected
+	c.writeLock()
+	defer c.unlock()
+
+	for e := c.evictList.Back(); e != nil; e = e.Prev() {
+		if val := c.valueOf(e); !val.negative && !val.expired(now) {
+			return val.key, val.value, true
+		}
+	}
+	return k, v, false
+}
+
+// PeekNewest returns the most recently used live entry without updating LRU order,
+// expired entries and cached misses are skipped, ok = false if there's no live entry
+func (c *Cache[K, V]) PeekNewest() (k K, v V, ok bool) {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	for e := c.evictList.Front(); e != nil; e = e.Next() {
+		if val := c.valueOf(e); !val.negative && !val.expired(now) {
+			return val.key, val.value, true
+		}
+	}
+	return k, v, false
+}
+
+// RemoveOldest evicts the least recently used live entry like Set does when the cache is full and returns it,
+// pinned entries are skipped, expired entries and cached misses found before it are removed too,
+// ok = false if there's no entry to evict
+func (c *Cache[K, V]) RemoveOldest() (k K, v V, ok bool) {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	for e := c.evictList.Back(); e != nil; {
+		prev := e.Prev()
+		val := c.valueOf(e)
+
+		switch {
+		case val.expired(now):
+			c.removeElement(e, ReasonExpired)
+		case val.negative:
+			c.removeElement(e, ReasonCapacity)
+		case !val.pinned:
+			c.removeElement(e, ReasonCapacity)
+			return val.key, val.value, true
+		}
+		e = prev
+	}
+	return k, v, false
+}
@@ -0,0 +1,545 @@
+package lru
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetWithoutExpirySkipsClock(t *testing.T) {
+	calls := 0
+	c, err := New[int, int](WithClock(func() time.Time {
+		calls++
+		return time.Unix(0, 0)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Set(1, 1)
+	calls = 0
+	for range 10 {
+		if _, ok := c.Get(1); !ok {
+			t.Fatal("Get(1) isn't presented")
+		}
+	}
+	if calls != 0 {
+		t.Errorf("Get of entry without expiry read the clock %d times, want 0", calls)
+	}
+}
+
+// evictRecorder records keys removed from the cache with their reasons
+type evictRecorder[K comparable] struct {
+	keys    []K
+	reasons []EvictReason
+}
+
+func (r *evictRecorder[K]) option() Option {
+	return WithOnEvict(func(k K, _ int, reason EvictReason) {
+		r.keys = append(r.keys, k)
+		r.reasons = append(r.reasons, reason)
+	})
+}
+
+func TestEvictionPrefersExpiredNearCapacity(t *testing.T) {
+	clock := newFakeClock()
+	rec := &evictRecorder[string]{}
+	c, err := New[string, int](WithCapacity(3), WithClock(clock.Now), rec.option())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Set("live1", 1)
+	c.SetWithTTL("expired", 2, time.Second)
+	c.Set("live2", 3)
+	clock.Add(time.Minute)
+
+	// the cache is full, the expired entry is evicted instead of the least recently used live one
+	c.Set("new", 4)
+
+	if len(rec.keys) != 1 || rec.keys[0] != "expired" || rec.reasons[0] != ReasonExpired {
+		t.Fatalf("evicted %v with reasons %v, want [expired] with ReasonExpired", rec.keys, rec.reasons)
+	}
+	for _, k := range []string{"live1", "live2", "new"} {
+		if !c.Contains(k) {
+			t.Errorf("live entry %q is evicted", k)
+		}
+	}
+
+	// no expired entries are left, so the least recently used live entry is evicted
+	c.Set("newer", 5)
+	if got := rec.keys[len(rec.keys)-1]; got != "live1" || rec.reasons[len(rec.reasons)-1] != ReasonCapacity {
+		t.Errorf("evicted %q, want live1 with ReasonCapacity", got)
+	}
+}
+
+func TestEvictionPrefersExpiredAnywhere(t *testing.T) {
+	const capacity = 100
+
+	clock := newFakeClock()
+	rec := &evictRecorder[int]{}
+	c, err := New[int, int](WithCapacity(capacity), WithClock(clock.Now), rec.option())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range capacity {
+		ttl := time.Duration(0)
+		// expired entries are the most recently used ones, far from the back of the evict list
+		if i >= capacity-10 {
+			ttl = time.Second + time.Duration(capacity-i)*time.Millisecond
+		}
+		c.SetWithTTL(i, i, ttl)
+	}
+	clock.Add(time.Minute)
+
+	for i := capacity; i < capacity+10; i++ {
+		c.Set(i, i)
+	}
+
+	if len(rec.keys) != 10 {
+		t.Fatalf("%d entries evicted, want 10", len(rec.keys))
+	}
+	for i, k := range rec.keys {
+		// expired entries are evicted in order of their expiry times
+		if want := capacity - 1 - i; k != want || rec.reasons[i] != ReasonExpired {
+			t.Errorf("eviction %d removed %d with %s, want %d with ReasonExpired", i, k, rec.reasons[i], want)
+		}
+	}
+	for i := range capacity - 10 {
+		if !c.Contains(i) {
+			t.Fatalf("live entry %d is evicted while expired entries were presented", i)
+		}
+	}
+}
+
+func TestNewCallbackTypeMismatch(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"WithOnEvict":   WithOnEvict(func(string, string, EvictReason) {}),
+		"WithLoader":    WithLoader(func(int) (string, error) { return "", nil }),
+		"WithSizer":     WithSizer(func(string, int) int64 { return 0 }),
+		"WithOnExpire":  WithOnExpire(func(string) {}),
+		"WithHasher":    WithHasher(func(string) uint64 { return 0 }),
+		"WithEncoder":   WithValueEncoder(func(string) ([]byte, error) { return nil, nil }),
+		"WithDecoder":   WithValueDecoder(func([]byte) (string, error) { return "", nil }),
+		"WithRefresh":   WithRefreshAhead(time.Second, func(string) (int, error) { return 0, nil }),
+		"WithEvictions": WithEvictionPolicy(func() EvictionPolicy[string, int] { return nil }),
+	} {
+		if _, err := New[int, int](opt); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("New with mismatched %s returned %v, want ErrInvalidOption", name, err)
+		}
+	}
+	if _, err := NewSharded[int, int](4, WithHasher(func(string) uint64 { return 0 })); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("NewSharded with mismatched hasher returned %v, want ErrInvalidOption", err)
+	}
+}
+
+func TestCapacityBoundaries(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{name: "default", want: DefaultCapacity},
+		{name: "one", opts: []Option{WithCapacity(1)}, want: 1},
+		{name: "max", opts: []Option{WithCapacity(math.MaxInt)}, want: math.MaxInt},
+		{name: "max cost without capacity", opts: []Option{WithMaxCost(10)}, want: math.MaxInt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New[int, int](tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Cap(); got != tt.want {
+				t.Errorf("Cap() = %d, want %d", got, tt.want)
+			}
+			for i := range 3 {
+				c.Set(i, i)
+			}
+			if want := min(3, tt.want); c.Len() != want {
+				t.Errorf("Len() = %d, want %d", c.Len(), want)
+			}
+			if _, ok := c.Peek(2); !ok {
+				t.Error("the most recent entry is evicted")
+			}
+		})
+	}
+}
+
+func TestMaxCostLimit(t *testing.T) {
+	c, err := New[int, int](WithMaxCost(math.MaxInt64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetWithCost(1, 1, MaxCostLimit)
+	c.SetWithCost(2, 2, MaxCostLimit)
+	if _, ok := c.Peek(2); !ok {
+		t.Error("entry with cost MaxCostLimit isn't set")
+	}
+	if _, ok := c.Peek(1); ok {
+		t.Error("total cost exceeds MaxCostLimit")
+	}
+}
+
+func TestBadElementKeepsPositions(t *testing.T) {
+	clock := newFakeClock()
+	c, err := New[int, int](WithCapacity(10), WithPolicy(PolicyRandom), WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.writeLock()
+	bad := c.evictList.PushFront("bad")
+	c.items[0] = bad
+	c.schedule(bad)
+	c.added(bad)
+	c.unlock()
+
+	for i := 1; i <= 5; i++ {
+		c.SetWithTTL(i, i, time.Duration(6-i)*time.Minute)
+	}
+	checkPositions(t, c)
+
+	if _, ok := c.Get(0); ok {
+		t.Error("bad element is served")
+	}
+
+	clock.Add(3*time.Minute + time.Second)
+	if n := c.RemoveExpired(); n != 3 {
+		t.Errorf("RemoveExpired() = %d, want 3", n)
+	}
+	checkPositions(t, c)
+	for i := 1; i <= 5; i++ {
+		if _, ok := c.Peek(i); ok != (i <= 2) {
+			t.Errorf("Peek(%d) found = %t, want %t", i, ok, i <= 2)
+		}
+	}
+
+	c.Delete(0)
+	checkPositions(t, c)
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+// checkPositions fails if entries' positions in the expiry queue or PolicyRandom sample are stale
+func checkPositions[K comparable, V any](t *testing.T, c *Cache[K, V]) {
+	t.Helper()
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for i, item := range c.expiry {
+		if item.val.expiryPos != i+1 || item.e.Value != any(item.val) {
+			t.Fatalf("expiry queue item %d has position %d", i, item.val.expiryPos-1)
+		}
+	}
+	for i, e := range c.sample {
+		if pos := c.valueOf(e).samplePos; pos != i+1 {
+			t.Fatalf("sample element %d has position %d", i, pos-1)
+		}
+	}
+}
+
+// largeValue is a value type big enough for copies of it to dominate lookups
+type largeValue [512]int64
+
+func BenchmarkGetLargeValue(b *testing.B) {
+	const keys = 1024
+
+	b.Run("Small", func(b *testing.B) {
+		benchmarkGet(b, keys, func(i int) int64 { return int64(i) })
+	})
+	b.Run("Large", func(b *testing.B) {
+		benchmarkGet(b, keys, func(i int) largeValue { return largeValue{int64(i)} })
+	})
+}
+
+// benchmarkGet measures Get hits, entries are stored by pointer, so only the returned value is copied
+func benchmarkGet[V any](b *testing.B, keys int, value func(i int) V) {
+	c, err := New[int, V](WithCapacity(keys))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := range keys {
+		c.Set(i, value(i))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := c.Get(i % keys); !ok {
+			b.Fatal("Get missed a set key")
+		}
+	}
+}
+
+func BenchmarkGetMulti(b *testing.B) {
+	const keys, batch = 1024, 8
+
+	c, err := New[int, int](WithCapacity(keys))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := range keys {
+		c.Set(i, i)
+	}
+	batches := make([][]int, keys/batch)
+	for i := range batches {
+		batches[i] = make([]int, batch)
+		for j := range batch {
+			batches[i][j] = (i*batch + j*37) % keys
+		}
+	}
+
+	b.Run("Sequential", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, k := range batches[i%len(batches)] {
+				c.Get(k)
+			}
+		}
+	})
+	b.Run("GetMulti", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.GetMulti(batches[i%len(batches)]...)
+		}
+	})
+	b.Run("SequentialParallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				for _, k := range batches[i%len(batches)] {
+					c.Get(k)
+				}
+				i++
+			}
+		})
+	})
+	b.Run("GetMultiParallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				c.GetMulti(batches[i%len(batches)]...)
+				i++
+			}
+		})
+	})
+}
+
+func BenchmarkFillToCapacity(b *testing.B) {
+	const capacity = 10_000
+
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "Presized"},
+		// a map sized for one entry grows while the cache fills like without pre-sizing
+		{name: "Growing", opts: []Option{WithInitialCapacity(1)}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				c, err := New[int, int](append(bm.opts, WithCapacity(capacity))...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for k := range capacity {
+					c.Set(k, k)
+				}
+			}
+		})
+	}
+}
+
+func TestSetManyOrder(t *testing.T) {
+	clock := newFakeClock()
+	c, err := New[string, int](WithCapacity(3), WithTTL(time.Hour), WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("a", 0)
+
+	c.SetMany([]Entry[string, int]{
+		{Key: "b", Value: 1},
+		{Key: "a", Value: 2},
+		{Key: "c", Value: 3, TTL: time.Minute},
+		{Key: "d", Value: 4},
+		{Key: "a", Value: 5},
+	})
+
+	if got, want := c.Keys(), []string{"a", "d", "c"}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if v, _ := c.Peek("a"); v != 5 {
+		t.Errorf("Peek(a) = %d, want the last value 5", v)
+	}
+
+	clock.Add(2 * time.Minute)
+	if _, ok := c.Peek("c"); ok {
+		t.Error("entry's own TTL isn't used")
+	}
+	if _, ok := c.Peek("d"); !ok {
+		t.Error("entry without own TTL doesn't use the cache TTL")
+	}
+}
+
+func TestMaxCostKeepsStoredEntry(t *testing.T) {
+	c, err := New[string, int](WithPolicy(PolicyLFU), WithMaxCost(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		c.SetWithCost(k, 0, 2)
+		c.Get(k)
+		c.Get(k)
+	}
+
+	// the new entry has the least frequency, but it's stored evicting the others to fit
+	c.SetWithCost("big", 9, 9)
+	if v, ok := c.Peek("big"); !ok || v != 9 {
+		t.Errorf("Peek(big) = %d, %t, want 9, true", v, ok)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", c.Len())
+	}
+
+	// big and x fit together
+	if k, ok := c.Put("x", 1); ok {
+		t.Errorf("Put(x) evicted %q", k)
+	}
+	if _, ok := c.Peek("x"); !ok {
+		t.Error("Put dropped the entry it stored")
+	}
+}
+
+func TestSlidingTTL(t *testing.T) {
+	const ttl = time.Minute
+
+	t.Run("Refresh", func(t *testing.T) {
+		clock := newFakeClock()
+		c, err := New[string, int](WithTTL(ttl), WithSlidingTTL(), WithClock(clock.Now))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Set("k", 1)
+
+		// hits every TTL/2 keep the entry alive well past its original expiry
+		for i := range 10 {
+			clock.Add(ttl / 2)
+			if _, ok := c.Get("k"); !ok {
+				t.Fatalf("Get(k) missed after %d hits", i)
+			}
+		}
+
+		clock.Add(ttl + time.Second)
+		if _, ok := c.Get("k"); ok {
+			t.Error("entry idle for TTL is served")
+		}
+	})
+
+	t.Run("MaxAge", func(t *testing.T) {
+		clock := newFakeClock()
+		c, err := New[string, int](WithTTL(ttl), WithSlidingTTL(), WithMaxAge(2*ttl), WithClock(clock.Now))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Set("k", 1)
+
+		for i := range 3 {
+			clock.Add(ttl / 2)
+			if _, ok := c.Get("k"); !ok {
+				t.Fatalf("Get(k) missed after %d hits", i)
+			}
+		}
+		// the expiry time is capped by max age since the entry was set, not refreshed by the last hit
+		if _, exp, ok := c.GetWithExpiry("k"); !ok || !exp.Equal(clock.Now().Add(ttl/2)) {
+			t.Errorf("GetWithExpiry(k) = %v, %t, want max age deadline %v", exp, ok, clock.Now().Add(ttl/2))
+		}
+
+		clock.Add(ttl/2 + time.Second)
+		if _, ok := c.Get("k"); ok {
+			t.Error("entry older than max age is served")
+		}
+	})
+}
+
+func TestGetMultiReadLock(t *testing.T) {
+	clock := newFakeClock()
+	c, err := New[string, int](WithCapacity(3), WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetWithTTL("a", 1, time.Minute)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	values, found := c.GetMulti("a", "x", "c")
+	if !slices.Equal(values, []int{1, 0, 3}) || !slices.Equal(found, []bool{true, false, true}) {
+		t.Errorf("GetMulti() = %v, %v, want [1 0 3], [true false true]", values, found)
+	}
+	// buffered promotions are applied by the next write, so b is the least recently used
+	c.Set("d", 4)
+	if got, want := c.Keys(), []string{"d", "c", "a"}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+
+	clock.Add(2 * time.Minute)
+	statuses := c.GetBatchStatus([]string{"a", "d"})
+	if statuses[0].Found || !statuses[1].Found || statuses[1].Value != 4 {
+		t.Errorf("GetBatchStatus() = %+v, want a expired and d found", statuses)
+	}
+	c.lock.RLock()
+	_, ok := c.items["a"]
+	c.lock.RUnlock()
+	if ok {
+		t.Error("expired entry found by GetBatchStatus isn't removed")
+	}
+}
+
+func TestClearReportsRemovedEntries(t *testing.T) {
+	for _, clear := range []struct {
+		name string
+		fn   func(c *Cache[int, int])
+	}{
+		{name: "Clear", fn: func(c *Cache[int, int]) { c.Clear() }},
+		{name: "Drain", fn: func(c *Cache[int, int]) { c.Drain() }},
+		{name: "Close", fn: func(c *Cache[int, int]) { _ = c.Close() }},
+	} {
+		t.Run(clear.name, func(t *testing.T) {
+			rec := &evictRecorder[int]{}
+			c, err := New[int, int](rec.option())
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := c.Events()
+			for i := range 3 {
+				c.Set(i, i)
+			}
+
+			clear.fn(c)
+
+			keys := slices.Sorted(slices.Values(rec.keys))
+			if !slices.Equal(keys, []int{0, 1, 2}) || slices.ContainsFunc(rec.reasons, func(r EvictReason) bool { return r != ReasonDeleted }) {
+				t.Errorf("evicted %v with reasons %v, want [0 1 2] with ReasonDeleted", keys, rec.reasons)
+			}
+			for i := range 3 {
+				select {
+				case ev := <-events:
+					if ev.Reason != ReasonDeleted || ev.Value != ev.Key {
+						t.Errorf("event %+v, want ReasonDeleted", ev)
+					}
+				default:
+					t.Fatalf("%d events received, want 3", i)
+				}
+			}
+		})
+	}
+}
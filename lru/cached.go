@@ -1,13 +1,92 @@
 package lru
 
-import "time"
+import (
+	"sync/atomic"
+	"time"
+)
 
 type cached[K comparable, V any] struct {
-	key       K
-	value     V
+	key   K
+	value V
+
+	// ttl is the entry's time-to-live used to compute expiredAt, zero value means entry never expires
+	ttl time.Duration
+	// expiredAt zero value means entry never expires
 	expiredAt time.Time
+	// createdAt is the time the entry was set, it limits the entry's lifetime by WithMaxAge
+	createdAt time.Time
+
+	// cost is counted against the cache max cost
+	cost int64
+	// size is the entry's size estimated by WithSizer
+	size int64
+	// frequency is the number of entry accesses tracked by PolicyLFU
+	frequency uint64
+	// priority defines the entry's band in the evict list
+	priority int
+	// version is set by SetWithVersion, entries set otherwise have zero version
+	version uint64
+	// pinned entries aren't evicted due to capacity
+	pinned bool
+	// referenced is set by Get hits with WithSecondChance, it's accessed atomically,
+	// because it's set under the read lock
+	referenced uint32
+	// promotedAt is the Unix time in nanoseconds the entry was set or moved to the front by Get hit last time,
+	// it throttles promotions by WithPromotionThrottle, it's accessed atomically like referenced
+	promotedAt int64
+	// pending is set while the entry's promotion is buffered, it's accessed atomically like referenced
+	pending uint32
+	// expiryPos is the entry's position in the expiry queue plus one, zero if it isn't queued
+	expiryPos int
+	// samplePos is the entry's position in PolicyRandom entries plus one, zero if it isn't sampled
+	samplePos int
+	// expireNotified is set once WithOnExpire hook is invoked for the entry, it's accessed atomically
+	expireNotified uint32
+	// negative marks a cached miss set by SetMiss, it holds no value
+	negative bool
+}
+
+// defaultCost is the cost of entries set without explicit cost
+const defaultCost int64 = 1
+
+func newCached[K comparable, V any](k K, v V, now time.Time, ttl time.Duration) *cached[K, V] {
+	return &cached[K, V]{
+		key:        k,
+		value:      v,
+		ttl:        ttl,
+		expiredAt:  expiration(now, ttl),
+		createdAt:  now,
+		promotedAt: now.UnixNano(),
+		cost:       defaultCost,
+	}
+}
+
+func (c *cached[K, V]) group() group {
+	return group{priority: c.priority, frequency: c.frequency}
 }
 
+// reference marks the entry used since it was moved to the front last time
+func (c *cached[K, V]) reference() {
+	if atomic.LoadUint32(&c.referenced) == 0 {
+		atomic.StoreUint32(&c.referenced, 1)
+	}
+}
+
+// unreference clears the entry's mark, returns true if it was set
+func (c *cached[K, V]) unreference() bool {
+	return atomic.SwapUint32(&c.referenced, 0) == 1
+}
+
+// expired is the only expiry check for entries, zero expiredAt is stored for entries without expiry,
+// so checking the cache TTL isn't needed and entries with own TTL expire regardless of it
 func (c *cached[K, V]) expired(now time.Time) bool {
-	return c.expiredAt.Before(now)
+	return !c.expiredAt.IsZero() && c.expiredAt.Before(now)
+}
+
+// expiration returns expiry time for specified TTL, zero TTL means entry never expires
+func expiration(now time.Time, ttl time.Duration) time.Time {
+	if ttl == 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
 }
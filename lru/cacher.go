@@ -0,0 +1,22 @@
+package lru
+
+import "time"
+
+// Cacher is the common API of Cache and ShardedCache, so code may use either of them
+// or an instrumented wrapper, the methods behave like Cache ones
+type Cacher[K comparable, V any] interface {
+	Set(k K, v V)
+	SetWithTTL(k K, v V, ttl time.Duration)
+	Get(k K) (value V, presented bool)
+	Peek(k K) (value V, presented bool)
+	Contains(k K) bool
+	Delete(k K) bool
+	Len() int
+	Clear()
+	Close() error
+}
+
+var (
+	_ Cacher[string, any] = (*Cache[string, any])(nil)
+	_ Cacher[string, any] = (*ShardedCache[string, any])(nil)
+)
@@ -0,0 +1,95 @@
+package lru
+
+import "container/list"
+
+// Clone returns an independent cache with the same options, capacity and TTL holding copies of the entries
+// in the same LRU order with the same expiry times. Values are copied shallowly, so values of pointer types,
+// slices and maps are shared by both caches. Stats aren't copied. The clone has its own janitor
+// if WithCleanupInterval is used, so it must be closed too
+func (c *Cache[K, V]) Clone() *Cache[K, V] {
+	c.writeLock()
+	defer c.unlock()
+
+	o := c.options
+	o.capacity = c.capacity
+	o.ttl = c.defaultTTL()
+	// options were checked on the cache creation
+	clone, _ := newCache[K, V](o)
+
+	// the clone's janitor may already run
+	clone.writeLock()
+	defer clone.unlock()
+
+	heads := make(map[*list.Element]group, len(c.groupHeads))
+	for g, head := range c.groupHeads {
+		heads[head] = g
+	}
+
+	for e := c.evictList.Front(); e != nil; e = e.Next() {
+		val := *c.valueOf(e)
+		val.expiryPos, val.samplePos, val.pending = 0, 0, 0
+		ce := clone.evictList.PushBack(&val)
+		clone.items[val.key] = ce
+		clone.schedule(ce)
+		if g, ok := heads[e]; ok {
+			clone.groupHeads[g] = ce
+		}
+	}
+	clone.addedAll()
+	clone.prioritized = c.prioritized
+	clone.totalCost = c.totalCost
+	clone.stats.bytes.Store(c.stats.bytes.Load())
+	return clone
+}
+
+// Filter returns an independent cache with the same options, capacity and TTL holding copies of the
+// non-expired entries matching the predicate in the same LRU order with the same expiry times.
+// It's a point-in-time snapshot like Clone, values are copied shallowly. The predicate is called
+// without holding the lock, so it may use the cache
+func (c *Cache[K, V]) Filter(pred func(k K, v V) bool) *Cache[K, V] {
+	entries := c.snapshot()
+
+	c.lock.RLock()
+	o := c.options
+	o.capacity = c.capacity
+	prioritized := c.prioritized
+	c.lock.RUnlock()
+	o.ttl = c.defaultTTL()
+	// options were checked on the cache creation
+	filtered, _ := newCache[K, V](o)
+
+	// the filtered cache's janitor may already run
+	filtered.writeLock()
+	defer filtered.unlock()
+
+	filtered.prioritized = prioritized
+
+	for i := range entries {
+		val := &entries[i]
+		if !pred(val.key, val.value) {
+			continue
+		}
+		val.expiryPos, val.samplePos, val.pending = 0, 0, 0
+		e := filtered.evictList.PushBack(val)
+		filtered.items[val.key] = e
+		filtered.schedule(e)
+		// the list is ordered by groups, so the first entry of a group is its head
+		if g := val.group(); filtered.grouped() {
+			if _, ok := filtered.groupHeads[g]; !ok {
+				filtered.groupHeads[g] = e
+			}
+		}
+		filtered.totalCost += val.cost
+		filtered.stats.bytes.Add(val.size)
+	}
+	filtered.addedAll()
+	return filtered
+}
+
+// addedAll registers entries of a filled cache like added from the least to the most recently used,
+// so the custom policy sees them added in the order they were used, lock must be held
+func (c *Cache[K, V]) addedAll() {
+	for e := c.evictList.Back(); e != nil; e = e.Prev() {
+		c.added(e)
+	}
+}
@@ -0,0 +1,101 @@
+package lru
+
+import (
+	"container/list"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCloneWithJanitor(t *testing.T) {
+	c, err := New[int, int](WithCapacity(1000), WithTTL(time.Hour), WithCleanupInterval(time.Microsecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 1000; i++ {
+		c.Set(i, i)
+	}
+
+	for i := 0; i < 10; i++ {
+		clone := c.Clone()
+		if n := clone.Len(); n != 1000 {
+			t.Errorf("clone Len() = %d, want 1000", n)
+		}
+		if v, ok := clone.Get(500); !ok || v != 500 {
+			t.Errorf("clone Get(500) = %d, %t, want 500, true", v, ok)
+		}
+		clone.Close()
+	}
+}
+
+func TestFilterWithJanitor(t *testing.T) {
+	c, err := New[int, int](WithCapacity(1000), WithTTL(time.Hour), WithCleanupInterval(time.Microsecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 1000; i++ {
+		c.Set(i, i)
+	}
+
+	for i := 0; i < 10; i++ {
+		filtered := c.Filter(func(k, _ int) bool { return k%2 == 0 })
+		if n := filtered.Len(); n != 500 {
+			t.Errorf("filtered Len() = %d, want 500", n)
+		}
+		if _, ok := filtered.Get(501); ok {
+			t.Error("filtered Get(501) is presented, want filtered out")
+		}
+		filtered.Close()
+	}
+}
+
+// fifoPolicy is an EvictionPolicy evicting entries in the order they were added
+type fifoPolicy struct {
+	order *list.List
+	elems map[*list.Element]*list.Element
+}
+
+func newFIFOPolicy() EvictionPolicy[string, int] {
+	return &fifoPolicy{order: list.New(), elems: make(map[*list.Element]*list.Element)}
+}
+
+func (p *fifoPolicy) OnAdd(e *list.Element, _ string, _ int) { p.elems[e] = p.order.PushBack(e) }
+func (p *fifoPolicy) OnAccess(*list.Element, string)         {}
+
+func (p *fifoPolicy) OnRemove(e *list.Element, _ string) {
+	if pe, ok := p.elems[e]; ok {
+		p.order.Remove(pe)
+		delete(p.elems, e)
+	}
+}
+
+func (p *fifoPolicy) Victim() *list.Element {
+	if front := p.order.Front(); front != nil {
+		return front.Value.(*list.Element)
+	}
+	return nil
+}
+
+func TestCloneEvictionPolicyOrder(t *testing.T) {
+	c, err := New[string, int](WithCapacity(3), WithEvictionPolicy(newFIFOPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, k := range []string{"a", "b", "c"} {
+		c.Set(k, i)
+	}
+
+	for name, copied := range map[string]*Cache[string, int]{
+		"Clone":  c.Clone(),
+		"Filter": c.Filter(func(string, int) bool { return true }),
+	} {
+		copied.Set("d", 3)
+		if got, want := copied.Keys(), []string{"d", "c", "b"}; !slices.Equal(got, want) {
+			t.Errorf("%s Keys() after Set = %v, want %v", name, got, want)
+		}
+	}
+}
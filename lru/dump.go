@@ -0,0 +1,51 @@
+package lru
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dumpLimit is the max number of entries written by String
+const dumpLimit = 100
+
+// String returns a human-readable dump of the cache: its size and capacity and entries ordered
+// from the most to the least recently used with their expiry times, an entry at the back is evicted first.
+// At most dumpLimit entries are written, the number of omitted ones is written instead of the rest
+func (c *Cache[K, V]) String() string {
+	now := c.now()
+
+	// write lock applies pending promotions, so the actual LRU order is dumped
+	c.writeLock()
+	defer c.unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Cache(size=%d, capacity=%d)", c.evictList.Len(), c.capacity)
+
+	i := 0
+	for e := c.evictList.Front(); e != nil; e = e.Next() {
+		if i == dumpLimit {
+			fmt.Fprintf(&b, "\n  ... %d more", c.evictList.Len()-dumpLimit)
+			break
+		}
+		i++
+
+		val := c.valueOf(e)
+		fmt.Fprintf(&b, "\n  %v", val.key)
+		switch {
+		case val.expiredAt.IsZero():
+			b.WriteString(" never expires")
+		case val.expired(now):
+			fmt.Fprintf(&b, " expired at %s", val.expiredAt.Format(time.RFC3339Nano))
+		default:
+			fmt.Fprintf(&b, " expires at %s", val.expiredAt.Format(time.RFC3339Nano))
+		}
+		if val.negative {
+			b.WriteString(" (cached miss)")
+		}
+		if val.pinned {
+			b.WriteString(" (pinned)")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package lru
+
+import "errors"
+
+var (
+	// ErrInvalidOption is returned by constructors when cache options can't be applied
+	ErrInvalidOption = errors.New("lru: invalid option")
+	// ErrClosed is returned by operations on the closed cache
+	ErrClosed = errors.New("lru: cache is closed")
+	// ErrNoLoader is returned by Load if the cache is created without WithLoader
+	ErrNoLoader = errors.New("lru: loader isn't set")
+	// ErrRecursiveLoad is returned by Load, GetOrCompute and GetOrComputeCtx called for a key by its own loader
+	// or compute function directly or via loads of other keys, the call would wait for itself otherwise
+	ErrRecursiveLoad = errors.New("lru: recursive load of the same key")
+	// ErrEntryTooLarge is returned by TrySet if the entry's size exceeds WithMaxEntrySize
+	ErrEntryTooLarge = errors.New("lru: entry is too large")
+)
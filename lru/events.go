@@ -0,0 +1,48 @@
+package lru
+
+// eventsBufferSize is the capacity of the channel returned by Events
+const eventsBufferSize = 256
+
+// Event describes an entry removed from the cache
+type Event[K comparable, V any] struct {
+	Key    K
+	Value  V
+	Reason EvictReason
+}
+
+// Events returns a buffered channel receiving an event for each entry removed from the cache
+// since the first call including ones removed by Clear, Drain and Close, repeated calls return the same channel. Events are sent without blocking
+// cache operations, so they're dropped while the channel is full. The channel is closed by Close
+func (c *Cache[K, V]) Events() <-chan Event[K, V] {
+	c.writeLock()
+	defer c.unlock()
+
+	if c.events == nil {
+		c.events = make(chan Event[K, V], eventsBufferSize)
+		if c.closed.Load() {
+			close(c.events)
+		}
+	}
+	return c.events
+}
+
+// emit sends the event of the removed entry if Events is used, lock must be held
+func (c *Cache[K, V]) emit(val *cached[K, V], reason EvictReason) {
+	if c.events == nil {
+		return
+	}
+	select {
+	case c.events <- Event[K, V]{Key: val.key, Value: val.value, Reason: reason}:
+	default:
+	}
+}
+
+// closeEvents closes the Events channel, cache must be closed
+func (c *Cache[K, V]) closeEvents() {
+	c.writeLock()
+	defer c.unlock()
+
+	if c.events != nil {
+		close(c.events)
+	}
+}
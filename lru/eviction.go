@@ -0,0 +1,78 @@
+package lru
+
+import "container/list"
+
+// EvictionPolicy selects entries evicted to free space instead of the built-in LRU and LFU order,
+// see WithEvictionPolicy. Elements are handles of the cache entries, which mustn't be modified.
+// Methods are called under the cache lock, so they mustn't call back into the cache. Their panics are
+// recovered and passed to WithErrorHandler like panics of callbacks, a panicking Victim selects nothing
+type EvictionPolicy[K comparable, V any] interface {
+	// OnAdd is called for an entry added to the cache
+	OnAdd(e *list.Element, k K, v V)
+	// OnAccess is called for an entry hit or updated, hits under the read lock are registered
+	// once their promotions are applied, WithNoRecencyUpdate and WithSecondChance hits aren't registered
+	OnAccess(e *list.Element, k K)
+	// OnRemove is called for an entry removed from the cache for any reason, the element
+	// mustn't be returned by Victim then
+	OnRemove(e *list.Element, k K)
+	// Victim returns an entry to evict, nil means the built-in selection is used
+	Victim() *list.Element
+}
+
+// safePolicy recovers panics of the user policy, so they can't break the cache invariants
+// while its methods are called under the lock
+type safePolicy[K comparable, V any] struct {
+	policy  EvictionPolicy[K, V]
+	onError func(r any)
+}
+
+func (p safePolicy[K, V]) OnAdd(e *list.Element, k K, v V) {
+	defer p.recover()
+	p.policy.OnAdd(e, k, v)
+}
+
+func (p safePolicy[K, V]) OnAccess(e *list.Element, k K) {
+	defer p.recover()
+	p.policy.OnAccess(e, k)
+}
+
+func (p safePolicy[K, V]) OnRemove(e *list.Element, k K) {
+	defer p.recover()
+	p.policy.OnRemove(e, k)
+}
+
+func (p safePolicy[K, V]) Victim() (e *list.Element) {
+	defer p.recover()
+	return p.policy.Victim()
+}
+
+func (p safePolicy[K, V]) recover() {
+	if v := recover(); v != nil && p.onError != nil {
+		p.onError(v)
+	}
+}
+
+// policyVictim returns the custom policy's victim, nil if there's no policy, the policy returns nil,
+// an element removed from the cache or a pinned entry, lock must be held
+func (c *Cache[K, V]) policyVictim() *list.Element {
+	if c.evictionPolicy == nil {
+		return nil
+	}
+	e := c.evictionPolicy.Victim()
+	if e == nil {
+		return nil
+	}
+	if val := c.valueOf(e); c.items[val.key] != e || val.pinned {
+		return nil
+	}
+	return e
+}
+
+// added registers the entry added to the evict list in PolicyRandom entries and the custom policy, lock must be held
+func (c *Cache[K, V]) added(e *list.Element) {
+	c.sampleEntry(e)
+	if c.evictionPolicy != nil {
+		val := c.valueOf(e)
+		c.evictionPolicy.OnAdd(e, val.key, val.value)
+	}
+}
@@ -0,0 +1,79 @@
+package lru
+
+import (
+	"container/heap"
+	"container/list"
+	"time"
+)
+
+// expiryQueue is a min-heap of elements holding entries with expiry time ordered by it,
+// so eviction finds an expired entry anywhere in the evict list without scanning it
+type expiryQueue[K comparable, V any] []queued[K, V]
+
+// queued is an element in the expiry queue with its entry, so the queue doesn't read elements' values
+type queued[K comparable, V any] struct {
+	e   *list.Element
+	val *cached[K, V]
+}
+
+func (q expiryQueue[K, V]) Len() int {
+	return len(q)
+}
+
+func (q expiryQueue[K, V]) Less(i, j int) bool {
+	return q[i].val.expiredAt.Before(q[j].val.expiredAt)
+}
+
+func (q expiryQueue[K, V]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].val.expiryPos = i + 1
+	q[j].val.expiryPos = j + 1
+}
+
+func (q *expiryQueue[K, V]) Push(x any) {
+	item := x.(queued[K, V])
+	*q = append(*q, item)
+	item.val.expiryPos = len(*q)
+}
+
+func (q *expiryQueue[K, V]) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = queued[K, V]{}
+	*q = old[:len(old)-1]
+	item.val.expiryPos = 0
+	return item
+}
+
+// schedule adds the element's entry to the expiry queue or updates its position
+// after its expiry time is changed, entries without expiry time aren't queued, lock must be held
+func (c *Cache[K, V]) schedule(e *list.Element) {
+	val := c.valueOf(e)
+	switch {
+	case val.expiryPos > 0 && val.expiredAt.IsZero():
+		heap.Remove(&c.expiry, val.expiryPos-1)
+	case val.expiryPos > 0:
+		heap.Fix(&c.expiry, val.expiryPos-1)
+	case !val.expiredAt.IsZero():
+		heap.Push(&c.expiry, queued[K, V]{e: e, val: val})
+	}
+}
+
+// unschedule removes the entry from the expiry queue, lock must be held
+func (c *Cache[K, V]) unschedule(val *cached[K, V]) {
+	if val.expiryPos > 0 {
+		heap.Remove(&c.expiry, val.expiryPos-1)
+	}
+}
+
+// nextExpired returns the element of the entry expiring first if it's already expired, nil otherwise,
+// lock must be held
+func (c *Cache[K, V]) nextExpired(now time.Time) *list.Element {
+	if len(c.expiry) == 0 {
+		return nil
+	}
+	if next := c.expiry[0]; next.val.expired(now) {
+		return next.e
+	}
+	return nil
+}
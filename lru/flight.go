@@ -0,0 +1,174 @@
+package lru
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+var errComputePanicked = errors.New("lru: compute function panicked")
+
+// flight is an in-progress or completed computation of a key's value
+type flight[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// goid is the ID of the goroutine computing the value, zero unless the computation is running
+	goid uint64
+}
+
+// flightGroup deduplicates concurrent computations of the same key, zero value is ready to use
+type flightGroup[K comparable, V any] struct {
+	lock    sync.Mutex
+	flights map[K]*flight[V]
+	// waits holds the flights waited for by goroutines' IDs, so a computation waiting for itself is detected
+	waits map[uint64]*flight[V]
+}
+
+// do runs fn once for concurrent calls with the same key, other callers wait for and share its result,
+// ErrRecursiveLoad is returned to a caller fn waits for, i.e. fn calls do for its own key
+func (g *flightGroup[K, V]) do(k K, fn func() (V, error)) (V, error) {
+	self := goid()
+	f, leader, err := g.joinAs(k, self)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	if !leader {
+		defer g.stopWaiting(self)
+		<-f.done
+		return f.value, f.err
+	}
+
+	defer g.finish(k, f)
+	g.computing(f, self)
+
+	// waiters receive errComputePanicked if fn panics, the panic itself continues in the calling goroutine
+	f.err = errComputePanicked
+	f.value, f.err = fn()
+	return f.value, f.err
+}
+
+// doCtx runs fn once for concurrent calls with the same key in a separate goroutine,
+// callers wait for its result until their context is done
+func (g *flightGroup[K, V]) doCtx(ctx context.Context, k K, fn func() (V, error)) (V, error) {
+	self := goid()
+	f, leader, err := g.joinAs(k, self)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	if leader {
+		go func() {
+			defer g.finish(k, f)
+			defer func() {
+				if r := recover(); r != nil {
+					f.err = fmt.Errorf("%w: %v", errComputePanicked, r)
+				}
+			}()
+
+			g.computing(f, goid())
+			f.value, f.err = fn()
+		}()
+	}
+	// the leader waits for the computation in another goroutine too
+	defer g.stopWaiting(self)
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// join returns the key's in-progress flight or starts a new one, leader = true for the started flight
+func (g *flightGroup[K, V]) join(k K) (f *flight[V], leader bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if f, ok := g.flights[k]; ok {
+		return f, false
+	}
+	if g.flights == nil {
+		g.flights = make(map[K]*flight[V])
+	}
+	f = &flight[V]{done: make(chan struct{})}
+	g.flights[k] = f
+	return f, true
+}
+
+// joinAs is join for the goroutine with the self ID, which waits for the flight unless it's the leader
+// computing it in the same goroutine. It returns ErrRecursiveLoad if the flight's computation waits for
+// the goroutine directly or via other flights, because the goroutine would wait for itself then
+func (g *flightGroup[K, V]) joinAs(k K, self uint64) (f *flight[V], leader bool, err error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	f, ok := g.flights[k]
+	if !ok {
+		if g.flights == nil {
+			g.flights = make(map[K]*flight[V])
+		}
+		f = &flight[V]{done: make(chan struct{})}
+		g.flights[k] = f
+	}
+	// a chain of waits has no cycles, because joining a flight closing one fails
+	for next := f; next != nil && next.goid != 0; next = g.waits[next.goid] {
+		if next.goid == self {
+			return nil, false, ErrRecursiveLoad
+		}
+	}
+	if g.waits == nil {
+		g.waits = make(map[uint64]*flight[V])
+	}
+	g.waits[self] = f
+	return f, !ok, nil
+}
+
+// computing marks the flight computed by the goroutine with the goid ID, the computing goroutine
+// doesn't wait for the flight
+func (g *flightGroup[K, V]) computing(f *flight[V], goid uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	f.goid = goid
+	if g.waits[goid] == f {
+		delete(g.waits, goid)
+	}
+}
+
+// stopWaiting removes the wait of the goroutine with the self ID
+func (g *flightGroup[K, V]) stopWaiting(self uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	delete(g.waits, self)
+}
+
+// goid returns the current goroutine's ID parsed from its stack trace header "goroutine N [...",
+// it's used only to detect computations waiting for themselves
+func goid() uint64 {
+	var buf [64]byte
+	header := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+	id, _ := strconv.ParseUint(string(header), 10, 64)
+	return id
+}
+
+// finish completes the flight waking up its waiters
+func (g *flightGroup[K, V]) finish(k K, f *flight[V]) {
+	g.lock.Lock()
+	delete(g.flights, k)
+	// goroutines still waiting for the completed flight don't wait for its computing goroutine anymore
+	f.goid = 0
+	g.lock.Unlock()
+	close(f.done)
+}
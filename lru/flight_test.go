@@ -0,0 +1,109 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadRecursive(t *testing.T) {
+	var c *Cache[string, string]
+	c, err := New[string, string](WithLoader(func(k string) (string, error) {
+		switch k {
+		case "self":
+			return c.Load(k)
+		case "a":
+			return c.Load("b")
+		case "b":
+			return c.Load("a")
+		}
+		return "loaded " + k, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{"self", "a"} {
+		done := make(chan error, 1)
+		go func() {
+			_, err := c.Load(k)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if !errors.Is(err, ErrRecursiveLoad) {
+				t.Errorf("Load(%s) error = %v, want ErrRecursiveLoad", k, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Load(%s) waits for itself", k)
+		}
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, recursive loads set values", c.Len())
+	}
+
+	if v, err := c.Load("x"); err != nil || v != "loaded x" {
+		t.Errorf("Load(x) = %q, %v, want loaded x", v, err)
+	}
+}
+
+func TestGetOrComputeCtxRecursive(t *testing.T) {
+	c, err := New[string, int]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// computations run in their own goroutines, so the cycle a, b, a spans two of them
+	var compute func(ctx context.Context, k string) (int, error)
+	compute = func(ctx context.Context, k string) (int, error) {
+		next := map[string]string{"self": "self", "a": "b", "b": "a"}[k]
+		return c.GetOrComputeCtx(ctx, next, func(ctx context.Context) (int, error) {
+			return compute(ctx, next)
+		})
+	}
+	for _, k := range []string{"self", "a"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := c.GetOrComputeCtx(ctx, k, func(ctx context.Context) (int, error) {
+			return compute(ctx, k)
+		})
+		cancel()
+		if !errors.Is(err, ErrRecursiveLoad) {
+			t.Errorf("GetOrComputeCtx(%s) error = %v, want ErrRecursiveLoad", k, err)
+		}
+	}
+}
+
+func TestLoadConcurrentWaiters(t *testing.T) {
+	release := make(chan struct{})
+	var loads sync.Map
+	c, err := New[int, int](WithLoader(func(k int) (int, error) {
+		if _, loaded := loads.LoadOrStore(k, true); loaded {
+			t.Errorf("key %d is loaded twice", k)
+		}
+		<-release
+		return k, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.Load(i % 2); err != nil || v != i%2 {
+				t.Errorf("Load(%d) = %d, %v", i%2, v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := len(c.flights.waits); n != 0 {
+		t.Errorf("%d waits are left", n)
+	}
+}
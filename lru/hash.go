@@ -0,0 +1,110 @@
+package lru
+
+import (
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// defaultHasher returns a hash function hashing keys consistently with their equality: strings and numbers
+// by their value, pointers and channels by their identity, structs, arrays and interfaces by their contents
+func defaultHasher[K comparable](seed maphash.Seed) func(K) uint64 {
+	return func(k K) uint64 {
+		switch v := any(k).(type) {
+		case string:
+			return maphash.String(seed, v)
+		case int:
+			return mix(uint64(v))
+		case int8:
+			return mix(uint64(v))
+		case int16:
+			return mix(uint64(v))
+		case int32:
+			return mix(uint64(v))
+		case int64:
+			return mix(uint64(v))
+		case uint:
+			return mix(uint64(v))
+		case uint8:
+			return mix(uint64(v))
+		case uint16:
+			return mix(uint64(v))
+		case uint32:
+			return mix(uint64(v))
+		case uint64:
+			return mix(v)
+		case uintptr:
+			return mix(uint64(v))
+		case float32:
+			return hashFloat(float64(v))
+		case float64:
+			return hashFloat(v)
+		default:
+			return hashValue(seed, reflect.ValueOf(&k).Elem())
+		}
+	}
+}
+
+// hashValue hashes keys of other types, e.g. named types, structs and pointers, walking them by reflection,
+// so equal keys have equal hashes and nothing is allocated unlike formatting keys
+func hashValue(seed maphash.Seed, v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.String:
+		return maphash.String(seed, v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return mix(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return mix(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return hashFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return mix(hashFloat(real(c)) ^ mix(hashFloat(imag(c))))
+	case reflect.Bool:
+		if v.Bool() {
+			return mix(1)
+		}
+		return 0
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		// the pointers are equal if they point to the same variable, the pointee's contents don't matter
+		return mix(uint64(uintptr(v.UnsafePointer())))
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return hashValue(seed, v.Elem())
+	case reflect.Array:
+		var h uint64
+		for i := range v.Len() {
+			h = mix(h ^ hashValue(seed, v.Index(i)))
+		}
+		return h
+	case reflect.Struct:
+		var h uint64
+		for i := range v.NumField() {
+			h = mix(h ^ hashValue(seed, v.Field(i)))
+		}
+		return h
+	default:
+		// other kinds aren't comparable
+		return 0
+	}
+}
+
+// hashFloat hashes a floating-point number by its value, +0 and -0 are equal keys, so both have zero hash
+func hashFloat(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return mix(math.Float64bits(f))
+}
+
+// mix spreads sequential integers across shards (splitmix64 finalizer)
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
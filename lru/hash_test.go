@@ -0,0 +1,86 @@
+package lru
+
+import (
+	"hash/maphash"
+	"math"
+	"testing"
+)
+
+func TestDefaultHasherEqualKeys(t *testing.T) {
+	type id string
+	type point struct {
+		X, Y float64
+	}
+	type ref struct {
+		p *int
+		n int
+	}
+
+	seed := maphash.MakeSeed()
+	negZero := math.Copysign(0, -1)
+
+	if defaultHasher[float64](seed)(0) != defaultHasher[float64](seed)(negZero) {
+		t.Error("+0 and -0 keys have different hashes")
+	}
+	if h := defaultHasher[id](seed); h("a") != h(id("a")) || h("a") == h("b") {
+		t.Error("named string keys aren't hashed by their value")
+	}
+	if h := defaultHasher[point](seed); h(point{X: 0, Y: 1}) != h(point{X: negZero, Y: 1}) {
+		t.Error("equal struct keys have different hashes")
+	}
+
+	n := 1
+	if h := defaultHasher[ref](seed); h(ref{p: &n, n: 1}) != h(ref{p: &n, n: 1}) {
+		t.Error("equal struct keys holding pointers have different hashes")
+	}
+	if h := defaultHasher[any](seed); h(any(1)) != h(any(1)) || h(any("a")) != h(any("a")) {
+		t.Error("equal interface keys have different hashes")
+	}
+}
+
+func TestShardedPointerKeys(t *testing.T) {
+	type key struct {
+		A int
+	}
+
+	s, err := NewSharded[*key, int](16, WithCapacity(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	keys := make([]*key, 100)
+	for i := range keys {
+		keys[i] = &key{A: i}
+		s.Set(keys[i], i)
+	}
+	// pointer keys are equal by identity, so changing the pointee mustn't reroute them
+	for _, k := range keys {
+		k.A += 1000
+	}
+
+	for i, k := range keys {
+		if v, ok := s.Get(k); !ok || v != i {
+			t.Fatalf("Get(%d) = %d, %t, want %d, true", i, v, ok, i)
+		}
+		if !s.Delete(k) {
+			t.Fatalf("Delete(%d) = false, want true", i)
+		}
+	}
+	if n := s.Len(); n != 0 {
+		t.Errorf("Len() = %d after deleting all keys, want 0", n)
+	}
+}
+
+func TestDefaultHasherAllocations(t *testing.T) {
+	type key struct {
+		Name string
+		ID   int
+	}
+
+	h := defaultHasher[key](maphash.MakeSeed())
+	k := key{Name: "name", ID: 1}
+	if allocs := testing.AllocsPerRun(100, func() { h(k) }); allocs != 0 {
+		t.Errorf("hashing struct key allocates %.0f times, want 0", allocs)
+	}
+}
@@ -0,0 +1,98 @@
+package lru
+
+import "iter"
+
+// Keys returns a copy of non-expired keys ordered from the most to the least recently used,
+// cached misses set by SetMiss are skipped
+func (c *Cache[K, V]) Keys() []K {
+	now := c.now()
+
+	// write lock applies pending promotions, so keys are returned in actual LRU order
+	c.writeLock()
+	defer c.unlock()
+
+	keys := make([]K, 0, c.evictList.Len())
+	for e := c.evictList.Front(); e != nil; e = e.Next() {
+		val := c.valueOf(e)
+		if !val.negative && !val.expired(now) {
+			keys = append(keys, val.key)
+		}
+	}
+	return keys
+}
+
+// Values returns a copy of non-expired values ordered from the most to the least recently used,
+// cached misses set by SetMiss are skipped
+func (c *Cache[K, V]) Values() []V {
+	entries := c.snapshot()
+	values := make([]V, 0, len(entries))
+	for _, val := range entries {
+		values = append(values, val.value)
+	}
+	return values
+}
+
+// Snapshot returns a point-in-time copy of non-expired entries, cached misses set by SetMiss are skipped
+func (c *Cache[K, V]) Snapshot() map[K]V {
+	entries := c.snapshot()
+	m := make(map[K]V, len(entries))
+	for _, val := range entries {
+		m[val.key] = val.value
+	}
+	return m
+}
+
+// All returns an iterator over non-expired entries ordered from the most to the least recently used.
+// Entries are copied under the lock when iteration starts and yielded without holding it,
+// so the loop body may modify the cache, such changes aren't visible to the running iteration.
+// Iteration doesn't update LRU order.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, val := range c.snapshot() {
+			if !yield(val.key, val.value) {
+				return
+			}
+		}
+	}
+}
+
+// ForEach calls fn for non-expired entries ordered from the most to the least recently used until it returns false.
+// Entries are copied under the lock before the first call, so fn may call back into the cache,
+// the copy may be stale relative to concurrent writes. It doesn't update LRU order
+func (c *Cache[K, V]) ForEach(fn func(k K, v V) bool) {
+	for _, val := range c.snapshot() {
+		if !fn(val.key, val.value) {
+			return
+		}
+	}
+}
+
+// OldestFirst returns an iterator over non-expired entries ordered from the least to the most recently used,
+// so entries are yielded in eviction order. It copies entries like All and doesn't update LRU order
+func (c *Cache[K, V]) OldestFirst() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		entries := c.snapshot()
+		for i := len(entries) - 1; i >= 0; i-- {
+			if !yield(entries[i].key, entries[i].value) {
+				return
+			}
+		}
+	}
+}
+
+// snapshot returns a copy of non-expired entries ordered from the most to the least recently used
+func (c *Cache[K, V]) snapshot() []cached[K, V] {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	entries := make([]cached[K, V], 0, c.evictList.Len())
+	for e := c.evictList.Front(); e != nil; e = e.Next() {
+		val := c.valueOf(e)
+		if !val.negative && !val.expired(now) {
+			entries = append(entries, *val)
+		}
+	}
+	return entries
+}
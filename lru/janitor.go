@@ -0,0 +1,103 @@
+package lru
+
+import "time"
+
+// defaultCleanupBatchSize is the number of entries removed by the janitor per lock acquisition
+// unless WithCleanupBatchSize is used
+const defaultCleanupBatchSize = 256
+
+// startJanitor runs a goroutine removing expired entries every interval until Close is called,
+// at most batchSize entries are removed per lock acquisition
+func (c *Cache[K, V]) startJanitor(interval time.Duration, batchSize int) {
+	c.cleanupBatchSize = batchSize
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.cleanup()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// cleanup removes expired entries in order of their expiry times taken from the expiry queue,
+// the lock is taken in short batches, so concurrent operations aren't blocked for the entire pass.
+// Entries are checked by their own expiry times, so entries set with own TTL by SetWithTTL
+// are removed by it regardless of the cache TTL
+func (c *Cache[K, V]) cleanup() {
+	for c.cleanupBatch() {
+	}
+}
+
+// cleanupBatch removes at most cleanupBatchSize expired entries expiring first,
+// returns false if no expired entries are left
+func (c *Cache[K, V]) cleanupBatch() bool {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	for range c.cleanupBatchSize {
+		// entries within the stale grace expire later than the rest of queued entries
+		if len(c.expiry) == 0 || !c.reclaimable(c.expiry[0].val, now) {
+			return false
+		}
+		c.removeElement(c.expiry[0].e, ReasonExpired)
+	}
+	return true
+}
+
+// RemoveExpired removes all expired entries with ReasonExpired and returns their number, it's a manual
+// alternative to WithCleanupInterval janitor. Entries within WithStaleGrace period are kept for GetStale.
+// It takes O(n) in the number of entries holding the lock
+func (c *Cache[K, V]) RemoveExpired() int {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	removed := 0
+	for e := c.evictList.Back(); e != nil; {
+		prev := e.Prev()
+		if val := c.valueOf(e); c.reclaimable(val, now) {
+			c.removeElement(e, ReasonExpired)
+			removed++
+		}
+		e = prev
+	}
+	return removed
+}
+
+// Close stops background goroutines started by the cache options, waits for them to exit
+// and removes all entries like Clear. Running WithRefreshAhead refreshes and WithOnExpire hooks are waited for too,
+// so they mustn't call Close. After Close Set is a no-op, so lookups always miss,
+// GetOrCompute returns ErrClosed, Events channel is closed. Repeated calls return ErrClosed.
+func (c *Cache[K, V]) Close() error {
+	if !c.closed.CompareAndSwap(false, true) {
+		return ErrClosed
+	}
+
+	if c.stop != nil {
+		close(c.stop)
+		<-c.done
+	}
+	// goroutines are started under the lock, so ones started before the cache is closed are added
+	// once the lock is acquired, later ones aren't started
+	c.lock.Lock()
+	c.lock.Unlock()
+	c.background.Wait()
+	c.Clear()
+	c.closeEvents()
+
+	return nil
+}
@@ -0,0 +1,347 @@
+package lru
+
+import (
+	"container/list"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for WithClock
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1_000_000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// sizeRecorder records sizes observed after each release of the cache write lock
+type sizeRecorder struct {
+	noopRecorder
+	mu    sync.Mutex
+	sizes []int
+}
+
+func (r *sizeRecorder) ObserveSize(size int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sizes = append(r.sizes, size)
+}
+
+func TestCleanupBatchSizeBoundsLockHold(t *testing.T) {
+	const (
+		expired   = 10000
+		live      = 100
+		batchSize = 64
+	)
+
+	clock := newFakeClock()
+	rec := &sizeRecorder{}
+	c, err := New[int, int](WithCapacity(expired+live), WithClock(clock.Now), WithMetrics(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the janitor is driven by cleanup calls, so batches aren't mixed with ticks
+	c.cleanupBatchSize = batchSize
+
+	for i := range expired + live {
+		ttl := time.Minute
+		if i%(expired/live+1) == 0 {
+			ttl = time.Hour
+		}
+		c.SetWithTTL(i, i, ttl)
+	}
+	clock.Add(2 * time.Minute)
+	rec.sizes = nil
+
+	c.cleanup()
+
+	if n := c.Len(); n != live {
+		t.Fatalf("Len() = %d after cleanup, want %d", n, live)
+	}
+	prev := expired + live
+	for _, size := range rec.sizes {
+		if removed := prev - size; removed > batchSize {
+			t.Fatalf("%d entries removed per lock acquisition, want at most %d", removed, batchSize)
+		}
+		prev = size
+	}
+	if len(rec.sizes) < expired/batchSize {
+		t.Errorf("lock is acquired %d times, want at least %d", len(rec.sizes), expired/batchSize)
+	}
+}
+
+func TestCleanupAfterPromotions(t *testing.T) {
+	clock := newFakeClock()
+	c, err := New[int, int](WithCapacity(100), WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.cleanupBatchSize = 2
+
+	for i := range 9 {
+		c.SetWithTTL(i, i, time.Minute)
+	}
+	c.SetWithTTL(100, 100, time.Hour)
+	// lookups and updates reorder the evict list, which mustn't affect the pass
+	c.Get(0)
+	c.SetWithTTL(4, 4, time.Minute)
+	clock.Add(2 * time.Minute)
+
+	c.cleanup()
+
+	if keys := c.Keys(); len(keys) != 1 || keys[0] != 100 {
+		t.Errorf("Keys() = %v after cleanup, want [100]", keys)
+	}
+}
+
+func TestCloseStopsJanitor(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	caches := make([]*Cache[int, int], 10)
+	for i := range caches {
+		c, err := New[int, int](WithTTL(time.Millisecond), WithCleanupInterval(time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Set(i, i)
+		caches[i] = c
+	}
+	if n := runtime.NumGoroutine(); n < before+len(caches) {
+		t.Fatalf("%d goroutines with janitors running, want at least %d", n, before+len(caches))
+	}
+
+	for _, c := range caches {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Close waits for the janitor to exit, so no goroutine is left
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after Close, want at most %d", n, before)
+	}
+	if err := caches[0].Close(); err != ErrClosed {
+		t.Errorf("repeated Close() = %v, want ErrClosed", err)
+	}
+}
+
+func TestJanitorPerEntryTTL(t *testing.T) {
+	clock := newFakeClock()
+	var (
+		mu      sync.Mutex
+		removed = map[string]bool{}
+	)
+	c, err := New[string, int](
+		WithTTL(time.Hour),
+		WithClock(clock.Now),
+		WithCleanupInterval(time.Millisecond),
+		WithOnEvict(func(k string, _ int, reason EvictReason) {
+			mu.Lock()
+			defer mu.Unlock()
+			if reason == ReasonExpired {
+				removed[k] = true
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.SetWithTTL("second", 0, time.Second)
+	c.SetWithTTL("minute", 0, time.Minute)
+	c.Set("hour", 0)
+	c.SetWithTTL("day", 0, 24*time.Hour)
+	// SetWithTTL zero TTL means the entry never expires regardless of the cache TTL
+	c.SetWithTTL("never", 0, 0)
+
+	clock.Add(2 * time.Minute)
+
+	want := map[string]bool{"second": true, "minute": true}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(removed)
+		mu.Unlock()
+		if n >= len(want) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// the janitor keeps running, so entries removed by mistake would be reported meanwhile
+	time.Sleep(10 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for k := range want {
+		if !removed[k] {
+			t.Errorf("entry %q past its own expiry isn't removed by the janitor", k)
+		}
+	}
+	for k := range removed {
+		if !want[k] {
+			t.Errorf("entry %q isn't expired but removed by the janitor", k)
+		}
+	}
+	for _, k := range []string{"hour", "day", "never"} {
+		if _, ok := c.Peek(k); !ok {
+			t.Errorf("Peek(%q) isn't presented, want the live entry kept", k)
+		}
+	}
+}
+
+func TestRemoveExpiredKeepsStale(t *testing.T) {
+	clock := newFakeClock()
+	c, err := New[string, int](WithClock(clock.Now), WithStaleGrace(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetWithTTL("stale", 1, time.Second)
+	c.SetWithTTL("reclaimable", 2, time.Second)
+	clock.Add(2 * time.Minute)
+	c.SetWithTTL("stale", 1, time.Second)
+	clock.Add(2 * time.Second)
+
+	if n := c.RemoveExpired(); n != 1 {
+		t.Errorf("RemoveExpired() = %d, want 1", n)
+	}
+	if v, stale, ok := c.GetStale("stale"); !ok || !stale || v != 1 {
+		t.Errorf("GetStale() = %d, %t, %t after RemoveExpired, want 1, true, true", v, stale, ok)
+	}
+	if _, _, ok := c.GetStale("reclaimable"); ok {
+		t.Error("entry expired before the stale grace is kept by RemoveExpired")
+	}
+}
+
+func TestPanickingOnEvict(t *testing.T) {
+	clock := newFakeClock()
+	var recovered atomic.Int64
+	c, err := New[int, int](
+		WithCapacity(4),
+		WithTTL(time.Minute),
+		WithClock(clock.Now),
+		WithCleanupInterval(time.Millisecond),
+		WithOnEvict(func(int, int, EvictReason) { panic("evict") }),
+		WithErrorHandler(func(r any) {
+			if r == "evict" {
+				recovered.Add(1)
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// capacity evictions panic in the caller's goroutine
+	for i := range 6 {
+		c.Set(i, i)
+	}
+	if n := recovered.Load(); n != 2 {
+		t.Fatalf("%d panics recovered on Set, want 2", n)
+	}
+	if v, ok := c.Get(5); !ok || v != 5 {
+		t.Errorf("Get(5) = %d, %t, want 5, true", v, ok)
+	}
+
+	// expirations panic in the janitor, it keeps removing entries of the next rounds
+	for round := range 2 {
+		clock.Add(2 * time.Minute)
+		want := int64(2 + 4*(round+1))
+		deadline := time.Now().Add(5 * time.Second)
+		for recovered.Load() < want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if n := recovered.Load(); n != want {
+			t.Fatalf("round %d: %d panics recovered, want %d", round, n, want)
+		}
+		c.lock.RLock()
+		left := c.evictList.Len()
+		c.lock.RUnlock()
+		if left != 0 {
+			t.Fatalf("round %d: janitor left %d expired entries", round, left)
+		}
+		for i := range 4 {
+			c.Set(i, i)
+		}
+	}
+}
+
+// panickingPolicy is an EvictionPolicy panicking in each method
+type panickingPolicy struct{}
+
+func (panickingPolicy) OnAdd(*list.Element, int, int) { panic("policy") }
+func (panickingPolicy) OnAccess(*list.Element, int)   { panic("policy") }
+func (panickingPolicy) OnRemove(*list.Element, int)   { panic("policy") }
+func (panickingPolicy) Victim() *list.Element         { panic("policy") }
+
+func TestPanickingEvictionPolicy(t *testing.T) {
+	clock := newFakeClock()
+	var recovered atomic.Int64
+	c, err := New[int, int](
+		WithCapacity(4),
+		WithTTL(time.Minute),
+		WithClock(clock.Now),
+		WithCleanupInterval(time.Millisecond),
+		WithEvictionPolicy(func() EvictionPolicy[int, int] { return panickingPolicy{} }),
+		WithErrorHandler(func(r any) {
+			if r == "policy" {
+				recovered.Add(1)
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the built-in selection is used when Victim panics
+	for i := range 6 {
+		c.Set(i, i)
+	}
+	if c.Len() != 4 {
+		t.Fatalf("Len() = %d, want 4", c.Len())
+	}
+	if v, ok := c.Get(5); !ok || v != 5 {
+		t.Errorf("Get(5) = %d, %t, want 5, true", v, ok)
+	}
+
+	// OnRemove panics in the janitor, it keeps removing expired entries
+	clock.Add(2 * time.Minute)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.lock.RLock()
+		left := c.evictList.Len()
+		c.lock.RUnlock()
+		if left == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor left %d expired entries", left)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if recovered.Load() == 0 {
+		t.Error("policy panics aren't passed to the error handler")
+	}
+	c.Set(1, 1)
+	if v, ok := c.Get(1); !ok || v != 1 {
+		t.Errorf("Get(1) = %d, %t, want 1, true", v, ok)
+	}
+}
@@ -0,0 +1,30 @@
+package lru
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// jitter randomizes entries' expiry times, so entries set together don't expire at the same instant
+type jitter struct {
+	fraction float64
+	// state of splitmix64 generator seeded per cache, so offsets aren't predictable across instances
+	state atomic.Uint64
+}
+
+func newJitter(fraction float64) *jitter {
+	j := &jitter{fraction: fraction}
+	j.state.Store(rand.Uint64())
+	return j
+}
+
+// offset returns a random offset within ±fraction of ttl, the jittered TTL is kept positive, it's safe for concurrent use
+func (j *jitter) offset(ttl time.Duration) time.Duration {
+	r := float64(mix(j.state.Add(0x9e3779b97f4a7c15))>>11) / (1 << 53)
+	offset := time.Duration((r*2 - 1) * j.fraction * float64(ttl))
+	if ttl+offset <= 0 {
+		return 1 - ttl
+	}
+	return offset
+}
@@ -0,0 +1,137 @@
+package lru
+
+import (
+	"fmt"
+	"iter"
+	"time"
+)
+
+// keyed is the KeyedCache entry holding the original key
+type keyed[K, V any] struct {
+	key   K
+	value V
+}
+
+// KeyedCache is a Cache for non-comparable keys, e.g. structs containing slices,
+// each key is mapped by the key function to a string used as the Cache key
+type KeyedCache[K, V any] struct {
+	cache *Cache[string, keyed[K, V]]
+	keyFn func(K) string
+}
+
+// NewKeyed creates a keyed cache configured by the options like New. Keys with equal keyFn results
+// are the same cache key, so keyFn must return distinct strings for distinct keys (e.g. their canonical encoding),
+// a hash of a key may collide. WithOnEvict callback receives the original keys, its types must be
+// func(K, V, EvictReason). WithHasher, WithLoader, WithSizer, WithOnExpire, WithRefreshAhead,
+// WithEvictionPolicy, WithValueEncoder and WithValueDecoder aren't supported, NewKeyed returns
+// ErrInvalidOption if any of them is provided
+func NewKeyed[K, V any](keyFn func(K) string, opts ...Option) (*KeyedCache[K, V], error) {
+	if keyFn == nil {
+		return nil, fmt.Errorf("%w: key function is nil", ErrInvalidOption)
+	}
+
+	o := applyOptions(opts)
+
+	for _, opt := range []struct {
+		name  string
+		value any
+	}{
+		{name: "WithHasher", value: o.hasher},
+		{name: "WithLoader", value: o.loader},
+		{name: "WithSizer", value: o.sizer},
+		{name: "WithOnExpire", value: o.onExpire},
+		{name: "WithRefreshAhead", value: o.refresh},
+		{name: "WithEvictionPolicy", value: o.newEvictionPolicy},
+		{name: "WithValueEncoder", value: o.encodeValue},
+		{name: "WithValueDecoder", value: o.decodeValue},
+	} {
+		if opt.value != nil {
+			return nil, fmt.Errorf("%w: %s isn't supported by keyed cache", ErrInvalidOption, opt.name)
+		}
+	}
+
+	onEvict, err := typedOption[func(K, V, EvictReason)](o.onEvict, "on evict callback")
+	if err != nil {
+		return nil, err
+	}
+	if onEvict != nil {
+		o.onEvict = func(_ string, kv keyed[K, V], reason EvictReason) {
+			onEvict(kv.key, kv.value, reason)
+		}
+	}
+
+	c, err := newCache[string, keyed[K, V]](o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyedCache[K, V]{cache: c, keyFn: keyFn}, nil
+}
+
+// Set sets a value for specified key to the cache
+func (c *KeyedCache[K, V]) Set(k K, v V) {
+	c.cache.Set(c.keyFn(k), keyed[K, V]{key: k, value: v})
+}
+
+// SetWithTTL sets a value for specified key to the cache with its own TTL, see Cache.SetWithTTL
+func (c *KeyedCache[K, V]) SetWithTTL(k K, v V, ttl time.Duration) {
+	c.cache.SetWithTTL(c.keyFn(k), keyed[K, V]{key: k, value: v}, ttl)
+}
+
+// Get looks up a key's value from the cache, presented = false if value expired or wasn't provided
+func (c *KeyedCache[K, V]) Get(k K) (value V, presented bool) {
+	kv, ok := c.cache.Get(c.keyFn(k))
+	return kv.value, ok
+}
+
+// Peek looks up a key's value from the cache without updating LRU order
+func (c *KeyedCache[K, V]) Peek(k K) (value V, presented bool) {
+	kv, ok := c.cache.Peek(c.keyFn(k))
+	return kv.value, ok
+}
+
+// Contains checks if the key is presented in the cache and not expired without updating LRU order
+func (c *KeyedCache[K, V]) Contains(k K) bool {
+	return c.cache.Contains(c.keyFn(k))
+}
+
+// Delete removes the key's entry from the cache, returns false if key wasn't presented
+func (c *KeyedCache[K, V]) Delete(k K) bool {
+	return c.cache.Delete(c.keyFn(k))
+}
+
+// Len returns the number of entries in the cache, see Cache.Len
+func (c *KeyedCache[K, V]) Len() int {
+	return c.cache.Len()
+}
+
+// Keys returns a copy of non-expired original keys ordered from the most to the least recently used
+func (c *KeyedCache[K, V]) Keys() []K {
+	values := c.cache.Values()
+	keys := make([]K, 0, len(values))
+	for _, kv := range values {
+		keys = append(keys, kv.key)
+	}
+	return keys
+}
+
+// All returns an iterator over non-expired entries with original keys, see Cache.All
+func (c *KeyedCache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, kv := range c.cache.All() {
+			if !yield(kv.key, kv.value) {
+				return
+			}
+		}
+	}
+}
+
+// Clear removes all entries from the cache
+func (c *KeyedCache[K, V]) Clear() {
+	c.cache.Clear()
+}
+
+// Close closes the underlying cache, see Cache.Close
+func (c *KeyedCache[K, V]) Close() error {
+	return c.cache.Close()
+}
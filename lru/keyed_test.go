@@ -0,0 +1,36 @@
+package lru
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewKeyedUnsupportedOptions(t *testing.T) {
+	keyFn := func(k []string) string { return strings.Join(k, "\x00") }
+
+	for name, opt := range map[string]Option{
+		"WithHasher":         WithHasher(func(string) uint64 { return 0 }),
+		"WithLoader":         WithLoader(func(string) (int, error) { return 0, nil }),
+		"WithSizer":          WithSizer(func(string, int) int64 { return 1 }),
+		"WithOnExpire":       WithOnExpire(func(string) {}),
+		"WithRefreshAhead":   WithRefreshAhead(time.Second, func(string) (int, error) { return 0, nil }),
+		"WithEvictionPolicy": WithEvictionPolicy(func() EvictionPolicy[string, int] { return nil }),
+		"WithValueEncoder":   WithValueEncoder(func(int) ([]byte, error) { return nil, nil }),
+		"WithValueDecoder":   WithValueDecoder(func([]byte) (int, error) { return 0, nil }),
+	} {
+		if _, err := NewKeyed[[]string, int](keyFn, opt); !errors.Is(err, ErrInvalidOption) || !strings.Contains(err.Error(), name) {
+			t.Errorf("NewKeyed(%s) error = %v, want ErrInvalidOption naming the option", name, err)
+		}
+	}
+
+	c, err := NewKeyed[[]string, int](keyFn, WithCapacity(2), WithOnEvict(func([]string, int, EvictReason) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set([]string{"a", "b"}, 1)
+	if v, ok := c.Get([]string{"a", "b"}); !ok || v != 1 {
+		t.Errorf("Get() = %d, %t, want 1, true", v, ok)
+	}
+}
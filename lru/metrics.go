@@ -0,0 +1,56 @@
+package lru
+
+// MetricRecorder receives the cache metrics, e.g. to export them to Prometheus or OpenTelemetry,
+// its methods are called synchronously, so they must be cheap and safe for concurrent use.
+// Panics of the methods are recovered and passed to WithErrorHandler handler
+type MetricRecorder interface {
+	// IncHit and IncMiss are called on lookups counted in Stats Hits and Misses
+	IncHit()
+	IncMiss()
+	// IncEviction is called for entries evicted due to capacity
+	IncEviction()
+	// ObserveSize is called with the number of cache entries after each modification,
+	// expired but not yet evicted entries are counted
+	ObserveSize(size int)
+}
+
+// noopRecorder is used if WithMetrics isn't provided
+type noopRecorder struct{}
+
+func (noopRecorder) IncHit()         {}
+func (noopRecorder) IncMiss()        {}
+func (noopRecorder) IncEviction()    {}
+func (noopRecorder) ObserveSize(int) {}
+
+// safeRecorder recovers panics of the user recorder, so they can't break the cache invariants
+// while its methods are called under the lock
+type safeRecorder struct {
+	recorder MetricRecorder
+	onError  func(r any)
+}
+
+func (r safeRecorder) IncHit() {
+	defer r.recover()
+	r.recorder.IncHit()
+}
+
+func (r safeRecorder) IncMiss() {
+	defer r.recover()
+	r.recorder.IncMiss()
+}
+
+func (r safeRecorder) IncEviction() {
+	defer r.recover()
+	r.recorder.IncEviction()
+}
+
+func (r safeRecorder) ObserveSize(size int) {
+	defer r.recover()
+	r.recorder.ObserveSize(size)
+}
+
+func (r safeRecorder) recover() {
+	if v := recover(); v != nil && r.onError != nil {
+		r.onError(v)
+	}
+}
@@ -0,0 +1,56 @@
+package lru
+
+import (
+	"fmt"
+	"time"
+)
+
+// State is the result of a key lookup distinguishing cached misses from unknown keys
+type State int
+
+const (
+	// StateUnknown means the key isn't presented in the cache or expired
+	StateUnknown State = iota
+	// StateHit means the key's value is presented in the cache
+	StateHit
+	// StateMiss means the key was cached as missing by SetMiss
+	StateMiss
+)
+
+func (s State) String() string {
+	switch s {
+	case StateUnknown:
+		return "unknown"
+	case StateHit:
+		return "hit"
+	case StateMiss:
+		return "miss"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// SetMiss caches the key as missing for the TTL, e.g. after a backend returned "not found",
+// zero TTL means the miss never expires, negative TTL is treated as zero.
+// Cached misses take the cache capacity, Get and other lookups treat them as absent keys,
+// GetState reports them as StateMiss, setting a value replaces the cached miss
+func (c *Cache[K, V]) SetMiss(k K, ttl time.Duration) {
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	var zero V
+	val := c.makeCached(k, zero, c.now(), ttl)
+	val.negative = true
+
+	c.writeLock()
+	defer c.unlock()
+
+	c.store(val)
+}
+
+// GetState looks up a key's value from the cache like Get, the state tells a cached miss from an unknown key
+func (c *Cache[K, V]) GetState(k K) (V, State) {
+	value, _, state := c.get(k)
+	return value, state
+}
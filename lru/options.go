@@ -1,20 +1,126 @@
 package lru
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
 
 type cacheOptions struct {
-	capacity int
-	ttl      time.Duration
+	capacity   int
+	ttl        time.Duration
+	slidingTTL bool
+	maxAge     time.Duration
+	staleGrace time.Duration
+	maxCost    int64
+	policy     Policy
+	policySet  bool
+
+	initialCapacity int
+	noRecencyUpdate bool
+	secondChance    bool
+	fixedExpiry     bool
+	promotionWindow time.Duration
+	ttlJitter       float64
+
+	cleanupInterval  time.Duration
+	cleanupBatchSize int
+	now              func() time.Time
+
+	// onEvict holds func(K, V, EvictReason), its types are checked by New
+	onEvict any
+	onFull  func()
+	onError func(r any)
+	metrics MetricRecorder
+	// hasher holds func(K) uint64, its types are checked by constructors
+	hasher any
+	// loader holds func(K) (V, error), its types are checked by New
+	loader any
+	// sizer holds func(K, V) int64, its types are checked by New
+	sizer        any
+	maxEntrySize int64
+	// onExpire holds func(K), its types are checked by New
+	onExpire any
+	// refresh holds func(K) (V, error), its types are checked by New
+	refresh          any
+	refreshThreshold time.Duration
+	// newEvictionPolicy holds func() EvictionPolicy[K, V], its types are checked by New
+	newEvictionPolicy any
+	// encodeValue holds func(V) ([]byte, error), decodeValue holds func([]byte) (V, error), their types are checked by New
+	encodeValue any
+	decodeValue any
+
+	admission bool
+	demotion  bool
+
+	// err holds conflicts between options found while applying them
+	err error
+	// invalidErr holds invalid option values, which are ignored unless WithStrictValidation is used
+	invalidErr error
+	strict     bool
 }
 
 type Option func(*cacheOptions)
 
-// WithCapacity ignoring negative and zero capacity values
+func (o *cacheOptions) conflict(err error) {
+	o.err = errors.Join(o.err, err)
+}
+
+func (o *cacheOptions) invalid(err error) {
+	o.invalidErr = errors.Join(o.invalidErr, err)
+}
+
+// typedOption returns the option value holding T, zero T if the option isn't set.
+// ErrInvalidOption is returned if the value holds another type, e.g. a callback for other cache types
+func typedOption[T any](v any, what string) (T, error) {
+	var zero T
+	if v == nil {
+		return zero, nil
+	}
+	typed, ok := v.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s %T doesn't match cache types", ErrInvalidOption, what, v)
+	}
+	return typed, nil
+}
+
+func applyOptions(opts []Option) cacheOptions {
+	var o cacheOptions
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(&o)
+	}
+	if o.now == nil {
+		o.now = time.Now
+	}
+	if o.metrics == nil {
+		o.metrics = noopRecorder{}
+	}
+	if o.maxEntrySize > 0 && o.sizer == nil {
+		o.conflict(errors.New("max entry size requires sizer"))
+	}
+	if o.capacity <= 0 {
+		o.capacity = DefaultCapacity
+		// entries number isn't limited by default if the cache is limited by cost
+		if o.maxCost > 0 {
+			o.capacity = math.MaxInt
+		}
+	}
+	return o
+}
+
+// WithCapacity ignoring negative and zero capacity values, DefaultCapacity is used then,
+// the effective capacity is returned by Cache.Cap
 func WithCapacity(capacity int) Option {
 	return func(o *cacheOptions) {
 		if capacity > 0 {
 			o.capacity = capacity
+			return
 		}
+		o.invalid(fmt.Errorf("capacity %d isn't positive", capacity))
 	}
 }
 
@@ -23,6 +129,348 @@ func WithTTL(ttl time.Duration) Option {
 	return func(o *cacheOptions) {
 		if ttl >= 0 {
 			o.ttl = ttl
+			return
+		}
+		o.invalid(fmt.Errorf("TTL %s is negative", ttl))
+	}
+}
+
+// WithOnEvict sets a callback invoked for entries removed from the cache with the reason of removal,
+// entries removed by Clear, Drain and Close are reported with ReasonDeleted, e.g. to release their resources.
+// K and V must match the cache types, otherwise New returns ErrInvalidOption
+func WithOnEvict[K, V any](fn func(k K, v V, reason EvictReason)) Option {
+	return func(o *cacheOptions) {
+		if fn != nil {
+			o.onEvict = fn
+		}
+	}
+}
+
+// WithHasher sets a key hash function used by NewSharded to route keys to shards and
+// by WithAdmission frequency sketch, K must match the cache key type, otherwise constructors return ErrInvalidOption
+func WithHasher[K comparable](fn func(k K) uint64) Option {
+	return func(o *cacheOptions) {
+		if fn != nil {
+			o.hasher = fn
+		}
+	}
+}
+
+// WithCleanupInterval starts a background janitor removing expired entries every interval,
+// the janitor must be stopped by Close, non-positive values are ignored
+func WithCleanupInterval(interval time.Duration) Option {
+	return func(o *cacheOptions) {
+		if interval > 0 {
+			o.cleanupInterval = interval
+			return
+		}
+		o.invalid(fmt.Errorf("cleanup interval %s isn't positive", interval))
+	}
+}
+
+// WithCleanupBatchSize sets the number of expired entries the WithCleanupInterval janitor removes per lock
+// acquisition, then it releases the lock and continues until no expired entries are left.
+// It bounds the latency a cleanup pass adds to concurrent operations, 256 entries are removed by default
+func WithCleanupBatchSize(n int) Option {
+	return func(o *cacheOptions) {
+		if n > 0 {
+			o.cleanupBatchSize = n
+			return
+		}
+		o.invalid(fmt.Errorf("cleanup batch size %d isn't positive", n))
+	}
+}
+
+// WithSlidingTTL makes each Get hit refresh the entry's expiry time by its TTL,
+// so frequently read entries stay alive while idle ones expire
+func WithSlidingTTL() Option {
+	return func(o *cacheOptions) {
+		o.slidingTTL = true
+	}
+}
+
+// WithMaxCost limits the total cost of entries evicting the least recently used ones to fit,
+// entries set without SetWithCost have cost 1. Unless WithCapacity is also provided,
+// entries number isn't limited. Non-positive values are ignored, values above MaxCostLimit are reduced to it
+func WithMaxCost(maxCost int64) Option {
+	return func(o *cacheOptions) {
+		if maxCost > MaxCostLimit {
+			o.invalid(fmt.Errorf("max cost %d exceeds MaxCostLimit", maxCost))
+		}
+		if maxCost > 0 {
+			o.maxCost = min(maxCost, MaxCostLimit)
+			return
+		}
+		o.invalid(fmt.Errorf("max cost %d isn't positive", maxCost))
+	}
+}
+
+// WithPolicy sets the eviction policy, PolicyLRU is used by default, unknown values are ignored,
+// providing different policies makes constructors return ErrInvalidOption
+func WithPolicy(p Policy) Option {
+	return func(o *cacheOptions) {
+		if !p.known() {
+			o.invalid(fmt.Errorf("unknown policy %s", p))
+			return
+		}
+		if o.policySet && o.policy != p {
+			o.conflict(fmt.Errorf("policy %s conflicts with policy %s", p, o.policy))
+			return
+		}
+		o.policy = p
+		o.policySet = true
+	}
+}
+
+// WithAdmission enables TinyLFU admission: key accesses are counted in a frequency sketch and
+// when the cache is full a new key is set only if it's estimated more frequent than the entry to be evicted,
+// so one-hit keys don't evict hot entries. Rejected Set calls are no-ops
+func WithAdmission() Option {
+	return func(o *cacheOptions) {
+		o.admission = true
+	}
+}
+
+// WithClock sets the function returning the current time used for TTL instead of time.Now,
+// e.g. to advance time deterministically in tests. The janitor interval is still measured in real time
+func WithClock(now func() time.Time) Option {
+	return func(o *cacheOptions) {
+		if now != nil {
+			o.now = now
+		}
+	}
+}
+
+// WithDemotion makes NewTiered store entries evicted from L1 due to capacity to L2
+func WithDemotion() Option {
+	return func(o *cacheOptions) {
+		o.demotion = true
+	}
+}
+
+// WithNoRecencyUpdate makes lookups like Get keep the eviction order, so the cache evicts entries
+// in order of their last set (TTL + FIFO cache) and hits don't take the write lock
+func WithNoRecencyUpdate() Option {
+	return func(o *cacheOptions) {
+		o.noRecencyUpdate = true
+	}
+}
+
+// WithTTLJitter randomizes each entry's expiry time by up to ±fraction of its TTL on set,
+// so entries set together don't expire at the same instant. Jittered TTL is always positive,
+// fractions above 1 are reduced to it, non-positive values are ignored
+func WithTTLJitter(fraction float64) Option {
+	return func(o *cacheOptions) {
+		if fraction > 1 {
+			o.invalid(fmt.Errorf("TTL jitter fraction %g exceeds 1", fraction))
+		}
+		if fraction > 0 {
+			o.ttlJitter = min(fraction, 1)
+			return
+		}
+		o.invalid(fmt.Errorf("TTL jitter fraction %g isn't positive", fraction))
+	}
+}
+
+// WithOnFull sets a callback invoked when the cache evicts an entry due to capacity for the first time,
+// it's invoked again only after the cache drops below capacity (e.g. after Delete or Clear) and fills up again
+func WithOnFull(fn func()) Option {
+	return func(o *cacheOptions) {
+		if fn != nil {
+			o.onFull = fn
+		}
+	}
+}
+
+// WithMetrics sets a recorder receiving hits, misses, evictions and the cache size,
+// so the cache metrics can be exported to any metrics library, nil recorder is ignored
+func WithMetrics(recorder MetricRecorder) Option {
+	return func(o *cacheOptions) {
+		if recorder != nil {
+			o.metrics = recorder
+		}
+	}
+}
+
+// WithErrorHandler sets a handler receiving values recovered from panics of user callbacks
+// like WithOnEvict, WithOnFull, WithMetrics and WithEvictionPolicy ones, panics are recovered silently by default
+func WithErrorHandler(fn func(r any)) Option {
+	return func(o *cacheOptions) {
+		if fn != nil {
+			o.onError = fn
+		}
+	}
+}
+
+// WithMaxAge limits entries' lifetime since they're set, so entries refreshed by WithSlidingTTL or Touch
+// expire after maxAge anyway and entries without TTL expire too. Non-positive values are ignored
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(o *cacheOptions) {
+		if maxAge > 0 {
+			o.maxAge = maxAge
+			return
+		}
+		o.invalid(fmt.Errorf("max age %s isn't positive", maxAge))
+	}
+}
+
+// WithLoader sets a function loading missing keys' values for Cache.Load,
+// K and V must match the cache types, otherwise New returns ErrInvalidOption
+func WithLoader[K comparable, V any](fn func(k K) (V, error)) Option {
+	return func(o *cacheOptions) {
+		if fn != nil {
+			o.loader = fn
+		}
+	}
+}
+
+// WithSecondChance makes Get hits mark entries referenced instead of moving them to the front,
+// so hits don't reorder the evict list. Eviction moves referenced entries to the front clearing their mark
+// and evicts the first unreferenced one (CLOCK-like second chance), LRU is used by default
+func WithSecondChance() Option {
+	return func(o *cacheOptions) {
+		o.secondChance = true
+	}
+}
+
+// WithFixedExpiry makes setting a value for a presented non-expired key keep the entry's expiry time,
+// so the entry expires a fixed duration after it's first set regardless of later updates. By default
+// each set resets the expiry time by the TTL. Touch and sliding TTL still refresh it
+func WithFixedExpiry() Option {
+	return func(o *cacheOptions) {
+		o.fixedExpiry = true
+	}
+}
+
+// WithInitialCapacity sets the number of entries the cache is allocated for on creation and by Clear,
+// it's reduced to the cache capacity if exceeds it. By default the cache is allocated for its capacity
+// limited to 65536 entries. Non-positive values are ignored
+func WithInitialCapacity(n int) Option {
+	return func(o *cacheOptions) {
+		if n > 0 {
+			o.initialCapacity = n
+			return
+		}
+		o.invalid(fmt.Errorf("initial capacity %d isn't positive", n))
+	}
+}
+
+// WithRefreshAhead makes Get hits of entries expiring within the threshold start their refresh in background,
+// the current value is returned without waiting. Concurrent hits of the same key start a single refresh,
+// on success the value is set with the entry's own TTL unless the key was removed, errors are ignored.
+// K and V must match the cache types, otherwise New returns ErrInvalidOption, non-positive threshold is ignored
+func WithRefreshAhead[K comparable, V any](threshold time.Duration, refresh func(k K) (V, error)) Option {
+	return func(o *cacheOptions) {
+		if threshold <= 0 {
+			o.invalid(fmt.Errorf("refresh ahead threshold %s isn't positive", threshold))
+			return
+		}
+		if refresh != nil {
+			o.refreshThreshold = threshold
+			o.refresh = refresh
+		}
+	}
+}
+
+// WithStaleGrace keeps expired entries for the grace period, so GetStale returns them marked stale,
+// e.g. to serve them while the value is refreshed. Lookups like Get miss expired entries anyway.
+// Non-positive values are ignored
+func WithStaleGrace(grace time.Duration) Option {
+	return func(o *cacheOptions) {
+		if grace > 0 {
+			o.staleGrace = grace
+			return
+		}
+		o.invalid(fmt.Errorf("stale grace %s isn't positive", grace))
+	}
+}
+
+// WithSizer sets a function estimating entries' sizes in bytes summed in Stats EstimatedBytes,
+// unlike WithMaxCost it doesn't affect eviction. The sum is as accurate as the sizer is.
+// K and V must match the cache types, otherwise New returns ErrInvalidOption
+func WithSizer[K comparable, V any](fn func(k K, v V) int64) Option {
+	return func(o *cacheOptions) {
+		if fn != nil {
+			o.sizer = fn
+		}
+	}
+}
+
+// WithPromotionThrottle makes Get hits move an entry to the front at most once per window,
+// so hot entries don't reorder the evict list on each hit. Non-positive values are ignored
+func WithPromotionThrottle(window time.Duration) Option {
+	return func(o *cacheOptions) {
+		if window > 0 {
+			o.promotionWindow = window
+			return
+		}
+		o.invalid(fmt.Errorf("promotion throttle window %s isn't positive", window))
+	}
+}
+
+// WithStrictValidation makes constructors return ErrInvalidOption if any option has an invalid value,
+// e.g. non-positive WithCapacity, instead of ignoring it, so misconfiguration is found early
+func WithStrictValidation() Option {
+	return func(o *cacheOptions) {
+		o.strict = true
+	}
+}
+
+// WithOnExpire sets a hook invoked in a new goroutine when a lookup like Get or Peek finds an expired entry,
+// e.g. to repopulate it in background. It's invoked once per expired entry even if concurrent lookups find it.
+// K must match the cache key type, otherwise New returns ErrInvalidOption
+func WithOnExpire[K comparable](fn func(k K)) Option {
+	return func(o *cacheOptions) {
+		if fn != nil {
+			o.onExpire = fn
+		}
+	}
+}
+
+// WithMaxEntrySize rejects entries with sizes estimated by WithSizer greater than maxBytes, existing entries
+// aren't evicted for them. Setting an oversized value for a presented key removes the old entry with
+// ReasonCapacity, so it isn't served instead of the new one, see TrySet. It requires WithSizer,
+// otherwise New returns ErrInvalidOption
+func WithMaxEntrySize(maxBytes int64) Option {
+	return func(o *cacheOptions) {
+		if maxBytes > 0 {
+			o.maxEntrySize = maxBytes
+			return
+		}
+		o.invalid(fmt.Errorf("max entry size %d isn't positive", maxBytes))
+	}
+}
+
+// WithEvictionPolicy sets a constructor of a custom policy selecting entries evicted to free space,
+// it's called once per cache, so each shard of NewSharded and each Clone get their own policy.
+// Expired entries are still evicted first, pinned victims and nil ones fall back to the built-in selection.
+// The evict list order used by iteration, PeekOldest and RemoveOldest isn't changed by the policy.
+// K and V must match the cache types, otherwise New returns ErrInvalidOption
+func WithEvictionPolicy[K comparable, V any](newPolicy func() EvictionPolicy[K, V]) Option {
+	return func(o *cacheOptions) {
+		if newPolicy != nil {
+			o.newEvictionPolicy = newPolicy
+		}
+	}
+}
+
+// WithValueEncoder sets a function encoding values persisted by MarshalJSON and SaveToFile instead of
+// the default encoding, e.g. to compress or encrypt them, values are persisted as byte slices then.
+// V must match the cache value type, otherwise New returns ErrInvalidOption
+func WithValueEncoder[V any](fn func(v V) ([]byte, error)) Option {
+	return func(o *cacheOptions) {
+		if fn != nil {
+			o.encodeValue = fn
+		}
+	}
+}
+
+// WithValueDecoder sets a function decoding values loaded by LoadJSON and LoadFromFile, which were encoded
+// by WithValueEncoder. V must match the cache value type, otherwise New returns ErrInvalidOption
+func WithValueDecoder[V any](fn func(data []byte) (V, error)) Option {
+	return func(o *cacheOptions) {
+		if fn != nil {
+			o.decodeValue = fn
 		}
 	}
 }
@@ -0,0 +1,192 @@
+package lru
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// entry is the persisted form of a cache entry, K and V must be serializable by the used encoding
+type entry[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+	// ExpiredAt zero value means entry never expires
+	ExpiredAt time.Time     `json:"expiredAt"`
+	TTL       time.Duration `json:"ttl"`
+	Cost      int64         `json:"cost"`
+	// CreatedAt is the time the entry was set, it limits the restored entry's lifetime by WithMaxAge,
+	// zero value means the entry is restored as set on load
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// entries returns non-expired entries ordered from the most to the least recently used
+func (c *Cache[K, V]) entries() []entry[K, V] {
+	snapshot := c.snapshot()
+	entries := make([]entry[K, V], len(snapshot))
+	for i, val := range snapshot {
+		entries[i] = entry[K, V]{
+			Key:       val.key,
+			Value:     val.value,
+			ExpiredAt: val.expiredAt,
+			TTL:       val.ttl,
+			Cost:      val.cost,
+			CreatedAt: val.createdAt,
+		}
+	}
+	return entries
+}
+
+// encodedEntries returns non-expired entries like entries, their values are encoded to []byte
+// if WithValueEncoder is used
+func (c *Cache[K, V]) encodedEntries() (any, error) {
+	entries := c.entries()
+	if c.encodeValue == nil {
+		return entries, nil
+	}
+
+	encoded := make([]entry[K, []byte], len(entries))
+	for i, ent := range entries {
+		data, err := c.encodeValue(ent.Value)
+		if err != nil {
+			return nil, fmt.Errorf("encode value of key %v: %w", ent.Key, err)
+		}
+		encoded[i] = entry[K, []byte]{
+			Key:       ent.Key,
+			Value:     data,
+			ExpiredAt: ent.ExpiredAt,
+			TTL:       ent.TTL,
+			Cost:      ent.Cost,
+			CreatedAt: ent.CreatedAt,
+		}
+	}
+	return encoded, nil
+}
+
+// decodeEntries decodes entries by decode, their values are decoded from []byte if WithValueDecoder is used
+func (c *Cache[K, V]) decodeEntries(decode func(v any) error) ([]entry[K, V], error) {
+	if c.decodeValue == nil {
+		var entries []entry[K, V]
+		if err := decode(&entries); err != nil {
+			return nil, err
+		}
+		return entries, nil
+	}
+
+	var encoded []entry[K, []byte]
+	if err := decode(&encoded); err != nil {
+		return nil, err
+	}
+	entries := make([]entry[K, V], len(encoded))
+	for i, ent := range encoded {
+		v, err := c.decodeValue(ent.Value)
+		if err != nil {
+			return nil, fmt.Errorf("decode value of key %v: %w", ent.Key, err)
+		}
+		entries[i] = entry[K, V]{
+			Key:       ent.Key,
+			Value:     v,
+			ExpiredAt: ent.ExpiredAt,
+			TTL:       ent.TTL,
+			Cost:      ent.Cost,
+			CreatedAt: ent.CreatedAt,
+		}
+	}
+	return entries, nil
+}
+
+// restore adds the entries ordered from the most to the least recently used to the cache
+// preserving their order and expiry times limited by WithMaxAge, already expired entries are skipped
+func (c *Cache[K, V]) restore(entries []entry[K, V]) {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	// inserting from back to front, so the most recently used entry ends up at the front
+	for i := len(entries) - 1; i >= 0; i-- {
+		ent := entries[i]
+		val := newCached(ent.Key, ent.Value, now, ent.TTL)
+		val.cost = ent.Cost
+		if !ent.CreatedAt.IsZero() {
+			val.createdAt = ent.CreatedAt
+		}
+		// expiry times are restored as saved, so the saved TTL jitter is kept
+		val.expiredAt = c.capAge(val, ent.ExpiredAt)
+		if val.expired(now) {
+			continue
+		}
+		c.store(val)
+	}
+}
+
+// MarshalJSON encodes non-expired entries with their expiry times as a JSON array
+// ordered from the most to the least recently used, K and V must be JSON serializable,
+// values are encoded by WithValueEncoder instead if it's used
+func (c *Cache[K, V]) MarshalJSON() ([]byte, error) {
+	entries, err := c.encodedEntries()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(entries)
+}
+
+// LoadJSON adds entries encoded by MarshalJSON to the cache preserving their LRU order and expiry times,
+// already expired entries are skipped, values are decoded by WithValueDecoder if it's used
+func (c *Cache[K, V]) LoadJSON(data []byte) error {
+	entries, err := c.decodeEntries(func(v any) error {
+		return json.Unmarshal(data, v)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.restore(entries)
+	return nil
+}
+
+// SaveToFile writes non-expired entries with their expiry times to the file using encoding/gob,
+// K and V must be gob serializable, concrete types stored in interface keys or values
+// must be registered with gob.Register, values are encoded by WithValueEncoder instead if it's used
+func (c *Cache[K, V]) SaveToFile(path string) (err error) {
+	entries, err := c.encodedEntries()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+
+	return gob.NewEncoder(f).Encode(entries)
+}
+
+// LoadFromFile creates a cache with entries written by SaveToFile preserving their LRU order and expiry times,
+// already expired entries are skipped, values are decoded by WithValueDecoder if it's used
+func LoadFromFile[K comparable, V any](path string, opts ...Option) (*Cache[K, V], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := New[K, V](opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := c.decodeEntries(gob.NewDecoder(f).Decode)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.restore(entries)
+
+	return c, nil
+}
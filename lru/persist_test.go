@@ -0,0 +1,195 @@
+package lru
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveToFileRoundTrip(t *testing.T) {
+	clock := newFakeClock()
+	c, err := New[string, int](WithCapacity(10), WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetWithTTL("expired", 0, time.Second)
+	c.Set("never", 1)
+	c.SetWithTTL("minute", 2, time.Minute)
+	c.SetWithCost("costly", 3, 5)
+	clock.Add(2 * time.Second)
+
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	if err := c.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadFromFile[string, int](path, WithCapacity(10), WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := []string{"costly", "minute", "never"}, loaded.Keys(); !slices.Equal(got, want) {
+		t.Errorf("loaded Keys() = %v, want %v in the same LRU order", got, want)
+	}
+	for k, want := range map[string]int{"never": 1, "minute": 2, "costly": 3} {
+		if v, ok := loaded.Peek(k); !ok || v != want {
+			t.Errorf("loaded Peek(%q) = %d, %t, want %d, true", k, v, ok, want)
+		}
+	}
+	_, expiresAt, _ := c.GetWithExpiry("minute")
+	if _, got, _ := loaded.GetWithExpiry("minute"); !got.Equal(expiresAt) {
+		t.Errorf("loaded expiry time = %s, want %s", got, expiresAt)
+	}
+
+	clock.Add(time.Minute)
+	if loaded.Contains("minute") {
+		t.Error("loaded entry doesn't expire by its saved expiry time")
+	}
+}
+
+func TestLoadJSONMaxAge(t *testing.T) {
+	clock := newFakeClock()
+	src, err := New[string, int](WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.Set("never", 1)
+	clock.Add(50 * time.Second)
+	src.Set("young", 2)
+
+	data, err := src.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New[string, int](WithClock(clock.Now), WithMaxAge(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadJSON(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// the entries' age is counted from the time they were set in the source cache
+	clock.Add(20 * time.Second)
+	if c.Contains("never") {
+		t.Error("loaded entry outlives WithMaxAge counted from its creation")
+	}
+	if !c.Contains("young") {
+		t.Error("loaded entry younger than WithMaxAge isn't presented")
+	}
+
+	clock.Add(time.Hour)
+	if c.Contains("young") {
+		t.Error("loaded entry without TTL outlives WithMaxAge")
+	}
+}
+
+// gzipCodec compresses string values persisted by the cache
+var gzipCodec = []Option{
+	WithValueEncoder(func(v string) ([]byte, error) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write([]byte(v)); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}),
+	WithValueDecoder(func(data []byte) (string, error) {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		v, err := io.ReadAll(r)
+		return string(v), err
+	}),
+}
+
+func TestValueCodecRoundTrip(t *testing.T) {
+	value := strings.Repeat("compressible ", 100)
+
+	c, err := New[int, string](gzipCodec...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set(1, value)
+	c.Set(2, "short")
+
+	data, err := c.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("compressible")) {
+		t.Error("MarshalJSON stored the value without the encoder")
+	}
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	if err := c.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	fromJSON, err := New[int, string](gzipCodec...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fromJSON.LoadJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := LoadFromFile[int, string](path, gzipCodec...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, loaded := range map[string]*Cache[int, string]{"LoadJSON": fromJSON, "LoadFromFile": fromFile} {
+		if v, ok := loaded.Peek(1); !ok || v != value {
+			t.Errorf("%s Peek(1) = %.20q, %t, want the original value", name, v, ok)
+		}
+		if v, ok := loaded.Peek(2); !ok || v != "short" {
+			t.Errorf("%s Peek(2) = %q, %t, want \"short\", true", name, v, ok)
+		}
+	}
+}
+
+func TestValueCodecErrors(t *testing.T) {
+	errCodec := errors.New("codec failed")
+
+	c, err := New[int, string](WithValueEncoder(func(string) ([]byte, error) { return nil, errCodec }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set(1, "v")
+	if _, err := c.MarshalJSON(); !errors.Is(err, errCodec) {
+		t.Errorf("MarshalJSON() error = %v, want the encoder error", err)
+	}
+	if err := c.SaveToFile(filepath.Join(t.TempDir(), "cache.gob")); !errors.Is(err, errCodec) {
+		t.Errorf("SaveToFile() error = %v, want the encoder error", err)
+	}
+
+	src, err := New[int, string](gzipCodec...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.Set(1, "v")
+	data, err := src.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := New[int, string](WithValueDecoder(func([]byte) (string, error) { return "", errCodec }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.LoadJSON(data); !errors.Is(err, errCodec) {
+		t.Errorf("LoadJSON() error = %v, want the decoder error", err)
+	}
+	if dst.Len() != 0 {
+		t.Error("entries are loaded despite the decoder error")
+	}
+}
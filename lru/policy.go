@@ -0,0 +1,241 @@
+package lru
+
+import (
+	"container/list"
+	"fmt"
+	"math/rand/v2"
+)
+
+// Policy defines how entries are ordered within a priority band of the evict list,
+// the back entry is evicted first
+type Policy int
+
+const (
+	// PolicyLRU evicts the least recently used entry
+	PolicyLRU Policy = iota
+	// PolicyLFU evicts the least frequently used entry, the least recently used one among equally frequent,
+	// entry's frequency is the number of its hits and updates
+	PolicyLFU
+	// PolicyRandom evicts a pseudo-randomly chosen entry, hits don't move entries, so there's no LRU bookkeeping
+	// on reads and scans don't flush the cache. Entries' priorities and WithSecondChance marks don't affect eviction
+	PolicyRandom
+	// PolicyFIFO evicts the first set entry, entries are never moved by hits, updates and Touch,
+	// so setting a value for a presented key keeps its position
+	PolicyFIFO
+)
+
+// randomVictimTries is the number of random entries checked for a non-pinned one before the evict list is scanned
+const randomVictimTries = 8
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyLRU:
+		return "LRU"
+	case PolicyLFU:
+		return "LFU"
+	case PolicyRandom:
+		return "Random"
+	case PolicyFIFO:
+		return "FIFO"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+// known reports whether the policy is defined by the package
+func (p Policy) known() bool {
+	return p == PolicyLRU || p == PolicyLFU || p == PolicyRandom || p == PolicyFIFO
+}
+
+// group is a set of entries with equal priority and frequency, the evict list is ordered by groups
+// descending, so the back entry belongs to the lowest priority, the least frequently used group.
+// Group heads are tracked only if PolicyLFU is used or any entry has non-zero priority,
+// otherwise all entries are in a single group and the list is a plain LRU list
+type group struct {
+	priority  int
+	frequency uint64
+}
+
+func (g group) less(other group) bool {
+	return g.priority < other.priority || g.priority == other.priority && g.frequency < other.frequency
+}
+
+// grouped reports whether group heads are tracked
+func (c *Cache[K, V]) grouped() bool {
+	return c.policy == PolicyLFU || c.prioritized
+}
+
+// enablePriorities starts tracking group heads of LRU list, which is a single group before, lock must be held
+func (c *Cache[K, V]) enablePriorities() {
+	if c.prioritized {
+		return
+	}
+	if c.policy != PolicyLFU {
+		if front := c.evictList.Front(); front != nil {
+			c.groupHeads[group{}] = front
+		}
+	}
+	c.prioritized = true
+}
+
+// pushEntry adds the new entry at the front of its group, lock must be held
+func (c *Cache[K, V]) pushEntry(val *cached[K, V]) *list.Element {
+	if c.policy == PolicyLFU {
+		val.frequency = 1
+	}
+	if val.priority != 0 {
+		c.enablePriorities()
+	}
+	if !c.grouped() {
+		return c.evictList.PushFront(val)
+	}
+
+	g := val.group()
+	var e *list.Element
+	if head, ok := c.groupHeads[g]; ok {
+		e = c.evictList.InsertBefore(val, head)
+	} else if next := c.nextGroupHead(g); next != nil {
+		e = c.evictList.InsertBefore(val, next)
+	} else {
+		e = c.evictList.PushBack(val)
+	}
+	c.groupHeads[g] = e
+	return e
+}
+
+// touch registers the entry access moving it to the front of its group, lock must be held
+func (c *Cache[K, V]) touch(e *list.Element) {
+	val := c.valueOf(e)
+	c.access(e, val.group())
+}
+
+// access registers the access of the entry, which belonged to the group from before,
+// PolicyLFU increments the entry's frequency, PolicyFIFO keeps the entry's position, lock must be held
+func (c *Cache[K, V]) access(e *list.Element, from group) {
+	val := c.valueOf(e)
+	if c.evictionPolicy != nil {
+		c.evictionPolicy.OnAccess(e, val.key)
+	}
+	if c.policy == PolicyFIFO && val.group() == from {
+		// FIFO entries are moved only to the group of their changed priority
+		return
+	}
+	if val.priority != 0 {
+		c.enablePriorities()
+	}
+	if !c.grouped() {
+		c.evictList.MoveToFront(e)
+		return
+	}
+
+	c.unlinkGroup(e, from)
+	if c.policy == PolicyLFU {
+		val.frequency++
+	}
+
+	g := val.group()
+	adjacent := from
+	adjacent.frequency++
+
+	switch head, ok := c.groupHeads[g]; {
+	case ok:
+		c.evictList.MoveBefore(e, head)
+	case g == from:
+		// the entry was alone in its group, so its place is unchanged
+	case g == adjacent:
+		// the entry's new group is right before its old one, which is empty if there's no head
+		if head, ok := c.groupHeads[from]; ok {
+			c.evictList.MoveBefore(e, head)
+		}
+	default:
+		if next := c.nextGroupHead(g); next == nil {
+			c.evictList.MoveToBack(e)
+		} else if next != e {
+			c.evictList.MoveBefore(e, next)
+		}
+	}
+	c.groupHeads[g] = e
+}
+
+// nextGroupHead returns the head of the greatest group less than g, nil if there's no one
+func (c *Cache[K, V]) nextGroupHead(g group) *list.Element {
+	var (
+		next      *list.Element
+		nextGroup group
+	)
+	for hg, head := range c.groupHeads {
+		if hg.less(g) && (next == nil || nextGroup.less(hg)) {
+			next, nextGroup = head, hg
+		}
+	}
+	return next
+}
+
+// unlinkEntry removes the entry from the evict list, lock must be held
+func (c *Cache[K, V]) unlinkEntry(e *list.Element) {
+	c.unsample(e)
+	if c.evictionPolicy != nil {
+		val := c.valueOf(e)
+		c.evictionPolicy.OnRemove(e, val.key)
+	}
+	if c.grouped() {
+		val := c.valueOf(e)
+		c.unlinkGroup(e, val.group())
+	}
+	c.evictList.Remove(e)
+}
+
+// unlinkGroup removes the element from the group keeping the group head valid
+func (c *Cache[K, V]) unlinkGroup(e *list.Element, g group) {
+	if c.groupHeads[g] != e {
+		return
+	}
+
+	if next := e.Next(); next != nil {
+		if val := c.valueOf(next); val.group() == g {
+			c.groupHeads[g] = next
+			return
+		}
+	}
+	delete(c.groupHeads, g)
+}
+
+// sampleEntry adds the element to the entries PolicyRandom chooses victims from, lock must be held
+func (c *Cache[K, V]) sampleEntry(e *list.Element) {
+	if c.policy != PolicyRandom {
+		return
+	}
+	val := c.valueOf(e)
+	if e.Value != any(val) {
+		// the position of an element holding another type would be lost
+		return
+	}
+	c.sample = append(c.sample, e)
+	val.samplePos = len(c.sample)
+}
+
+// unsample removes the element from PolicyRandom entries moving the last one to its position, lock must be held
+func (c *Cache[K, V]) unsample(e *list.Element) {
+	val := c.valueOf(e)
+	if val.samplePos == 0 {
+		return
+	}
+
+	i, last := val.samplePos-1, len(c.sample)-1
+	c.sample[i] = c.sample[last]
+	c.valueOf(c.sample[i]).samplePos = i + 1
+	c.sample[last] = nil
+	c.sample = c.sample[:last]
+	val.samplePos = 0
+}
+
+// randomVictim returns a random non-pinned entry except keep, nil if none is found within randomVictimTries,
+// lock must be held
+func (c *Cache[K, V]) randomVictim(keep *list.Element) *list.Element {
+	for i := 0; i < randomVictimTries && len(c.sample) > 0; i++ {
+		if e := c.sample[rand.IntN(len(c.sample))]; e != keep && !c.valueOf(e).pinned {
+			return e
+		}
+	}
+	return nil
+}
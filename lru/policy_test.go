@@ -0,0 +1,74 @@
+package lru
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func BenchmarkPolicyUniform(b *testing.B) {
+	const capacity = 1000
+
+	for _, bm := range []struct {
+		name   string
+		policy Policy
+		// keys is the number of accessed keys, only hits are measured if it doesn't exceed capacity
+		keys int
+	}{
+		{name: "LRU", policy: PolicyLRU, keys: 4 * capacity},
+		{name: "Random", policy: PolicyRandom, keys: 4 * capacity},
+		{name: "LRUHits", policy: PolicyLRU, keys: capacity},
+		{name: "RandomHits", policy: PolicyRandom, keys: capacity},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			r := rand.New(rand.NewPCG(1, 2))
+			trace := make([]int, 1<<16)
+			for i := range trace {
+				trace[i] = r.IntN(bm.keys)
+			}
+
+			c, err := New[int, int](WithCapacity(capacity), WithPolicy(bm.policy))
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, k := range trace {
+				if _, ok := c.Get(k); !ok {
+					c.Set(k, k)
+				}
+			}
+			c.ResetStats()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				k := trace[i%len(trace)]
+				if _, ok := c.Get(k); !ok {
+					c.Set(k, k)
+				}
+			}
+			b.StopTimer()
+
+			st := c.Stats()
+			b.ReportMetric(100*float64(st.Hits)/float64(st.Hits+st.Misses), "hit%")
+		})
+	}
+}
+
+func TestRandomMaxCostKeepsStoredEntry(t *testing.T) {
+	for run := range 200 {
+		c, err := New[int, int](WithPolicy(PolicyRandom), WithMaxCost(10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range 10 {
+			c.Set(i, i)
+		}
+
+		c.SetWithCost(100, 100, 5)
+		if _, ok := c.Peek(100); !ok {
+			t.Fatalf("run %d: the stored entry is evicted to fit max cost", run)
+		}
+		if c.Len() != 6 {
+			t.Fatalf("run %d: Len() = %d, want 6", run, c.Len())
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package lru
+
+import (
+	"container/list"
+	"sync/atomic"
+)
+
+const promotionsBufferSize = 64
+
+// promotions buffers elements hit under the read lock to move them to the front under the write lock.
+// Readers reserve slots by the counter and fill them while holding the read lock, so slots are read
+// under the write lock without races. An entry is buffered once until its promotion is applied,
+// so repeated hits of hot entries only check the entry's pending mark
+type promotions struct {
+	reserved atomic.Int64
+	slots    [promotionsBufferSize]atomic.Pointer[list.Element]
+}
+
+// promote buffers moving the hit element to the front, read lock must be held.
+// Returns false if the buffer is full, the promotion must be applied by applyFullPromotion then
+func (c *Cache[K, V]) promote(e *list.Element, val *cached[K, V]) bool {
+	if atomic.LoadUint32(&val.pending) == 1 || !atomic.CompareAndSwapUint32(&val.pending, 0, 1) {
+		// the entry is buffered already
+		return true
+	}
+
+	i := c.promotions.reserved.Add(1) - 1
+	if i >= promotionsBufferSize {
+		atomic.StoreUint32(&val.pending, 0)
+		return false
+	}
+	c.promotions.slots[i].Store(e)
+	return true
+}
+
+// applyFullPromotion applies pending promotions and the elements' ones, which didn't fit the buffer.
+// The promotions are dropped if the lock is taken, so readers don't queue for it under contention
+// and LRU order is approximate then, the next hits of the entries promote them again
+func (c *Cache[K, V]) applyFullPromotion(elems ...*list.Element) {
+	if !c.lock.TryLock() {
+		return
+	}
+	c.applyPromotions()
+	for _, e := range elems {
+		c.applyPromotion(e)
+	}
+	c.lock.Unlock()
+}
+
+// applyPromotions moves buffered elements to the front in order of hits, write lock must be held
+func (c *Cache[K, V]) applyPromotions() {
+	n := min(c.promotions.reserved.Load(), promotionsBufferSize)
+	for i := range n {
+		e := c.promotions.slots[i].Swap(nil)
+		atomic.StoreUint32(&c.valueOf(e).pending, 0)
+		c.applyPromotion(e)
+	}
+	c.promotions.reserved.Store(0)
+}
+
+// applyPromotion registers the element access if it's still in the cache, write lock must be held
+func (c *Cache[K, V]) applyPromotion(e *list.Element) {
+	if val := c.valueOf(e); c.items[val.key] == e {
+		c.touch(e)
+	}
+}
+
+// removeExpired removes the entry found expired under the read lock unless it was updated meanwhile
+func (c *Cache[K, V]) removeExpired(k K, e *list.Element) {
+	c.writeLock()
+	defer c.unlock()
+
+	cur, ok := c.items[k]
+	if !ok || cur != e {
+		return
+	}
+	if val := c.valueOf(e); c.reclaimable(val, c.now()) {
+		c.removeElement(e, ReasonExpired)
+	}
+}
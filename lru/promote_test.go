@@ -0,0 +1,104 @@
+package lru
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestPromotionsKeepHitEntries(t *testing.T) {
+	const capacity = 4 * promotionsBufferSize
+
+	c, err := New[int, int](WithCapacity(capacity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range capacity {
+		c.Set(i, i)
+	}
+	// hits overflow the buffer, so promotions are applied both on overflow and by the next write
+	hot := 2 * promotionsBufferSize
+	for range 3 {
+		for i := range hot {
+			c.Get(i)
+		}
+	}
+	for i := capacity; i < capacity+capacity-hot; i++ {
+		c.Set(i, i)
+	}
+
+	for i := range hot {
+		if !c.Contains(i) {
+			t.Fatalf("hit key %d is evicted, want the key kept", i)
+		}
+	}
+	for i := hot; i < capacity; i++ {
+		if c.Contains(i) {
+			t.Fatalf("key %d isn't hit but kept, want the key evicted", i)
+		}
+	}
+}
+
+func TestPromotionsConcurrent(t *testing.T) {
+	c, err := New[int, int](WithCapacity(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 10000 {
+				k := (g*31 + i) % 150
+				if i%10 == 0 {
+					c.Set(k, k)
+				} else if v, ok := c.Get(k); ok && v != k {
+					t.Errorf("Get(%d) = %d, want %d", k, v, k)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(c.Keys()); n > 100 {
+		t.Errorf("cache holds %d keys, want at most 100", n)
+	}
+}
+
+// BenchmarkGetParallel compares hits served under the read lock with buffered promotions to hits
+// served under the write lock, which WithSlidingTTL still uses like Get did before read locking
+func BenchmarkGetParallel(b *testing.B) {
+	const keys = 1024
+
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "ReadLock"},
+		{name: "WriteLock", opts: []Option{WithSlidingTTL()}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			c, err := New[string, int](append(bm.opts, WithCapacity(keys))...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			names := make([]string, keys)
+			for i := range names {
+				names[i] = strconv.Itoa(i)
+				c.Set(names[i], i)
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.Get(names[i%keys])
+					i++
+				}
+			})
+		})
+	}
+}
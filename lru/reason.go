@@ -0,0 +1,26 @@
+package lru
+
+// EvictReason describes why an entry was removed from the cache
+type EvictReason int
+
+const (
+	// ReasonCapacity means the least recently used entry was evicted to free space for a new one
+	ReasonCapacity EvictReason = iota
+	// ReasonExpired means the entry was removed after its TTL elapsed
+	ReasonExpired
+	// ReasonDeleted means the entry was removed explicitly
+	ReasonDeleted
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case ReasonCapacity:
+		return "capacity"
+	case ReasonExpired:
+		return "expired"
+	case ReasonDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
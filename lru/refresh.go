@@ -0,0 +1,97 @@
+package lru
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// refreshAhead starts the key's refresh by WithRefreshAhead function if its entry expires within the threshold,
+// concurrent hits of the same key start a single refresh
+func (c *Cache[K, V]) refreshAhead(k K, expiredAt time.Time) {
+	if expiredAt.IsZero() || expiredAt.Sub(c.now()) > c.refreshThreshold || c.closed.Load() {
+		return
+	}
+
+	f, leader := c.refreshes.join(k)
+	if !leader {
+		return
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	e, ok := c.items[k]
+	if !ok {
+		c.refreshes.finish(k, f)
+		return
+	}
+	// the entry is set again while refreshing if it isn't the element's value anymore
+	from := c.valueOf(e)
+
+	started := c.goBackground(func() {
+		defer c.refreshes.finish(k, f)
+
+		// the panic is recovered by callback leaving the error set
+		f.err = errComputePanicked
+		c.callback(func() {
+			f.value, f.err = c.refresh(k)
+		})
+		if f.err == nil {
+			c.refreshed(k, f.value, from)
+		}
+	})
+	if !started {
+		c.refreshes.finish(k, f)
+	}
+}
+
+// refreshed sets the refreshed value with the entry's own TTL, the key isn't set
+// if it was removed or set again while refreshing, so a newer value isn't overwritten
+func (c *Cache[K, V]) refreshed(k K, v V, from *cached[K, V]) {
+	now := c.now()
+
+	c.writeLock()
+	defer c.unlock()
+
+	e, ok := c.items[k]
+	if !ok {
+		return
+	}
+	old := c.valueOf(e)
+	if old != from || old.negative {
+		return
+	}
+
+	val := c.makeCached(k, v, now, old.ttl)
+	val.cost, val.priority = old.cost, old.priority
+	c.store(val)
+}
+
+// notifyExpired invokes WithOnExpire hook in a new goroutine once per expired entry,
+// so concurrent lookups of the expired key invoke it once, lock must be held
+func (c *Cache[K, V]) notifyExpired(val *cached[K, V]) {
+	if c.onExpire == nil || !atomic.CompareAndSwapUint32(&val.expireNotified, 0, 1) {
+		return
+	}
+
+	k := val.key
+	c.goBackground(func() {
+		c.callback(func() {
+			c.onExpire(k)
+		})
+	})
+}
+
+// goBackground runs fn in a new goroutine Close waits for, it returns false without running fn
+// if the cache is closed, lock must be held, so Close doesn't miss it
+func (c *Cache[K, V]) goBackground(fn func()) bool {
+	if c.closed.Load() {
+		return false
+	}
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		fn()
+	}()
+	return true
+}
@@ -0,0 +1,82 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshAheadKeepsNewerSet(t *testing.T) {
+	clock := newFakeClock()
+	started, release := make(chan struct{}), make(chan struct{})
+	c, err := New[string, string](
+		WithTTL(time.Minute),
+		WithClock(clock.Now),
+		WithRefreshAhead(10*time.Second, func(string) (string, error) {
+			close(started)
+			<-release
+			return "refreshed-old", nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Set("k", "v")
+	clock.Add(55 * time.Second)
+	if v, ok := c.Get("k"); !ok || v != "v" {
+		t.Fatalf("Get(k) = %q, %t, want v, true", v, ok)
+	}
+	<-started
+
+	c.Set("k", "new")
+	close(release)
+	c.background.Wait()
+
+	if v, _ := c.Peek("k"); v != "new" {
+		t.Errorf("Peek(k) = %q, want the value set while refreshing", v)
+	}
+}
+
+func TestCloseWaitsForRefresh(t *testing.T) {
+	clock := newFakeClock()
+	started, release := make(chan struct{}), make(chan struct{})
+	c, err := New[string, string](
+		WithTTL(time.Minute),
+		WithClock(clock.Now),
+		WithRefreshAhead(10*time.Second, func(string) (string, error) {
+			close(started)
+			<-release
+			return "refreshed", nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Set("k", "v")
+	clock.Add(55 * time.Second)
+	c.Get("k")
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		_ = c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close returned while the refresh is running")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close didn't return after the refresh finished")
+	}
+	if c.Len() != 0 {
+		t.Errorf("refreshed value is set after Close")
+	}
+}
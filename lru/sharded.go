@@ -0,0 +1,155 @@
+package lru
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"time"
+)
+
+// ShardedCache is a set of independent caches, each key is routed to a single shard by its hash,
+// which reduces lock contention under high concurrency.
+//
+// Keys are hashed consistently with their equality unless a hash function is supplied with WithHasher:
+// strings and numbers by their value, pointers and channels by their identity,
+// structs, arrays and interfaces by their contents, so changing a pointee doesn't reroute its pointer key.
+type ShardedCache[K comparable, V any] struct {
+	shards []*Cache[K, V]
+	hash   func(K) uint64
+}
+
+// NewSharded creates a cache consisting of the specified number of shards,
+// capacity set by WithCapacity and max cost set by WithMaxCost are divided across shards rounding up,
+// so each shard holds at least one entry
+func NewSharded[K comparable, V any](shards int, opts ...Option) (*ShardedCache[K, V], error) {
+	if shards <= 0 {
+		return nil, fmt.Errorf("%w: shards number %d must be positive", ErrInvalidOption, shards)
+	}
+
+	o := applyOptions(opts)
+
+	o.capacity = divideCeil(o.capacity, shards)
+	if o.maxCost > 0 {
+		o.maxCost = divideCeil(o.maxCost, int64(shards))
+	}
+
+	s := &ShardedCache[K, V]{
+		shards: make([]*Cache[K, V], shards),
+		// shards use their own default hashers seeded differently, so admission sketches aren't skewed by routing
+		hash: defaultHasher[K](maphash.MakeSeed()),
+	}
+	for i := range s.shards {
+		c, err := newCache[K, V](o)
+		if err != nil {
+			_ = s.Close()
+			return nil, err
+		}
+		s.shards[i] = c
+	}
+	if o.hasher != nil {
+		// the hasher's type is checked by newCache
+		s.hash = s.shards[0].hash
+	}
+
+	return s, nil
+}
+
+func (s *ShardedCache[K, V]) shard(k K) *Cache[K, V] {
+	return s.shards[s.hash(k)%uint64(len(s.shards))]
+}
+
+// Set sets a value for specified key to the cache
+func (s *ShardedCache[K, V]) Set(k K, v V) {
+	s.shard(k).Set(k, v)
+}
+
+// SetWithTTL sets a value for specified key to the cache with its own TTL, see Cache.SetWithTTL
+func (s *ShardedCache[K, V]) SetWithTTL(k K, v V, ttl time.Duration) {
+	s.shard(k).SetWithTTL(k, v, ttl)
+}
+
+// Get looks up a key's value from the cache, presented = false if value expired or wasn't provided
+func (s *ShardedCache[K, V]) Get(k K) (value V, presented bool) {
+	return s.shard(k).Get(k)
+}
+
+// Peek looks up a key's value from the cache without updating LRU order
+func (s *ShardedCache[K, V]) Peek(k K) (value V, presented bool) {
+	return s.shard(k).Peek(k)
+}
+
+// Contains checks if the key is presented in the cache and not expired without updating LRU order
+func (s *ShardedCache[K, V]) Contains(k K) bool {
+	return s.shard(k).Contains(k)
+}
+
+// Delete removes the key's entry from the cache, returns false if key wasn't presented
+func (s *ShardedCache[K, V]) Delete(k K) bool {
+	return s.shard(k).Delete(k)
+}
+
+// Len returns the number of live entries across all shards
+func (s *ShardedCache[K, V]) Len() int {
+	n := 0
+	for _, c := range s.shards {
+		n += c.Len()
+	}
+	return n
+}
+
+// Stats returns the cache statistics summed across all shards, capacity is saturated at math.MaxInt
+func (s *ShardedCache[K, V]) Stats() Stats {
+	var total Stats
+	for _, st := range s.ShardStats() {
+		total.Hits += st.Hits
+		total.Misses += st.Misses
+		total.Evictions += st.Evictions
+		total.Expirations += st.Expirations
+		total.Size += st.Size
+		total.EstimatedBytes += st.EstimatedBytes
+		if total.Capacity > math.MaxInt-st.Capacity {
+			total.Capacity = math.MaxInt
+		} else {
+			total.Capacity += st.Capacity
+		}
+	}
+	return total
+}
+
+// ShardStats returns the statistics of each shard, e.g. to find hot shards caused by a poor key hash distribution
+func (s *ShardedCache[K, V]) ShardStats() []Stats {
+	stats := make([]Stats, len(s.shards))
+	for i, c := range s.shards {
+		stats[i] = c.Stats()
+	}
+	return stats
+}
+
+// Clear removes all entries from all shards
+func (s *ShardedCache[K, V]) Clear() {
+	for _, c := range s.shards {
+		c.Clear()
+	}
+}
+
+// Close closes all shards, see Cache.Close
+func (s *ShardedCache[K, V]) Close() error {
+	var errs []error
+	for _, c := range s.shards {
+		if c != nil {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// divideCeil divides positive total across shards rounding up, so each shard gets at least 1,
+// it doesn't overflow unlike (total + shards - 1) / shards
+func divideCeil[T int | int64](total, shards T) T {
+	n := total / shards
+	if total%shards != 0 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,62 @@
+package lru
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDivideCeil(t *testing.T) {
+	tests := []struct {
+		total, shards, want int
+	}{
+		{total: 1, shards: 1, want: 1},
+		{total: 1, shards: 16, want: 1},
+		{total: 16, shards: 16, want: 1},
+		{total: 17, shards: 16, want: 2},
+		{total: 128, shards: 3, want: 43},
+		{total: math.MaxInt, shards: 1, want: math.MaxInt},
+		{total: math.MaxInt, shards: 2, want: math.MaxInt/2 + 1},
+		{total: math.MaxInt, shards: math.MaxInt, want: 1},
+	}
+	for _, tt := range tests {
+		if got := divideCeil(tt.total, tt.shards); got != tt.want {
+			t.Errorf("divideCeil(%d, %d) = %d, want %d", tt.total, tt.shards, got, tt.want)
+		}
+	}
+	if got := divideCeil(int64(math.MaxInt64), 3); got != math.MaxInt64/3+1 {
+		t.Errorf("divideCeil(MaxInt64, 3) = %d, want %d", got, int64(math.MaxInt64/3+1))
+	}
+}
+
+func TestNewShardedCapacitySplit(t *testing.T) {
+	tests := []struct {
+		name         string
+		shards       int
+		opts         []Option
+		wantPerShard int
+		wantCapacity int
+	}{
+		{name: "one entry", shards: 4, opts: []Option{WithCapacity(1)}, wantPerShard: 1, wantCapacity: 4},
+		{name: "rounded up", shards: 3, opts: []Option{WithCapacity(10)}, wantPerShard: 4, wantCapacity: 12},
+		{name: "default", shards: 16, wantPerShard: DefaultCapacity / 16, wantCapacity: DefaultCapacity},
+		{name: "max", shards: 2, opts: []Option{WithCapacity(math.MaxInt)}, wantPerShard: math.MaxInt/2 + 1, wantCapacity: math.MaxInt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSharded[int, int](tt.shards, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			for i, st := range s.ShardStats() {
+				if st.Capacity != tt.wantPerShard {
+					t.Errorf("shard %d capacity = %d, want %d", i, st.Capacity, tt.wantPerShard)
+				}
+			}
+			if got := s.Stats().Capacity; got != tt.wantCapacity {
+				t.Errorf("Stats().Capacity = %d, want %d", got, tt.wantCapacity)
+			}
+		})
+	}
+}
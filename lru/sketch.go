@@ -0,0 +1,87 @@
+package lru
+
+import (
+	"container/list"
+	"sync/atomic"
+)
+
+const (
+	sketchDepth = 4
+	// sketchMaxCounter is the counter saturation value, 4 bits are enough to tell hot keys from cold ones
+	sketchMaxCounter = 15
+	sketchMinWidth   = 16
+	sketchMaxWidth   = 1 << 20
+	// sketchSampleFactor defines the number of increments per counter, after which counters are halved,
+	// so the sketch reflects recent frequencies
+	sketchSampleFactor = 10
+)
+
+var sketchSeeds = [sketchDepth]uint64{0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325}
+
+// sketch is a count-min sketch estimating key access frequencies, safe for concurrent use
+type sketch struct {
+	rows      [sketchDepth][]atomic.Uint32
+	mask      uint64
+	additions atomic.Uint64
+	resetAt   uint64
+}
+
+func newSketch(capacity int) *sketch {
+	width := sketchMinWidth
+	for width < capacity && width < sketchMaxWidth {
+		width <<= 1
+	}
+
+	s := &sketch{
+		mask:    uint64(width - 1),
+		resetAt: uint64(width) * sketchSampleFactor,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]atomic.Uint32, width)
+	}
+	return s
+}
+
+func (s *sketch) increment(h uint64) {
+	for i := range s.rows {
+		counter := &s.rows[i][mix(h^sketchSeeds[i])&s.mask]
+		for {
+			n := counter.Load()
+			if n >= sketchMaxCounter || counter.CompareAndSwap(n, n+1) {
+				break
+			}
+		}
+	}
+
+	if s.additions.Add(1) == s.resetAt {
+		s.reset()
+	}
+}
+
+func (s *sketch) estimate(h uint64) uint32 {
+	var estimate uint32 = sketchMaxCounter
+	for i := range s.rows {
+		estimate = min(estimate, s.rows[i][mix(h^sketchSeeds[i])&s.mask].Load())
+	}
+	return estimate
+}
+
+// reset halves all counters, concurrent increments may be lost, which is fine for an estimate
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			counter := &s.rows[i][j]
+			counter.Store(counter.Load() / 2)
+		}
+	}
+	s.additions.Store(0)
+}
+
+// admit reports whether the new key may evict the victim, lock must be held
+func (c *Cache[K, V]) admit(k K, victim *list.Element, reason EvictReason) bool {
+	if c.admission == nil || victim == nil || reason != ReasonCapacity {
+		return true
+	}
+	v := c.valueOf(victim)
+	return c.admission.estimate(c.hash(k)) > c.admission.estimate(c.hash(v.key))
+}
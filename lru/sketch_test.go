@@ -0,0 +1,56 @@
+package lru
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// zipfKeys returns n keys in [0, keys) drawn from a Zipfian distribution, a few keys are hot
+// and most are cold like in typical cache workloads
+func zipfKeys(n int, keys uint64) []uint64 {
+	z := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, keys-1)
+	trace := make([]uint64, n)
+	for i := range trace {
+		trace[i] = z.Uint64()
+	}
+	return trace
+}
+
+func BenchmarkHitRatioZipf(b *testing.B) {
+	const capacity = 1000
+	trace := zipfKeys(1<<20, 100*capacity)
+
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "LRU"},
+		{name: "Admission", opts: []Option{WithAdmission()}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			c, err := New[uint64, uint64](append(bm.opts, WithCapacity(capacity))...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			// warm up the cache and the sketch, so the ratio isn't skewed by cold misses
+			for _, k := range trace {
+				if _, ok := c.Get(k); !ok {
+					c.Set(k, k)
+				}
+			}
+			c.ResetStats()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				k := trace[i%len(trace)]
+				if _, ok := c.Get(k); !ok {
+					c.Set(k, k)
+				}
+			}
+			b.StopTimer()
+
+			st := c.Stats()
+			b.ReportMetric(100*float64(st.Hits)/float64(st.Hits+st.Misses), "hit%")
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package lru
+
+import "time"
+
+// GetStale looks up a key's value from the cache like Get, expired entries within WithStaleGrace period
+// are returned with stale = true, ok = false if value expired earlier or wasn't provided
+func (c *Cache[K, V]) GetStale(k K) (value V, stale bool, ok bool) {
+	if v, ok := c.Get(k); ok {
+		return v, false, true
+	}
+	if c.staleGrace <= 0 {
+		return value, false, false
+	}
+
+	now := c.now()
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	e, found := c.items[k]
+	if !found {
+		return value, false, false
+	}
+	val := c.valueOf(e)
+	if val.negative || c.reclaimable(val, now) {
+		return value, false, false
+	}
+	// the entry may be set again after Get missed it
+	return val.value, val.expired(now), true
+}
+
+// reclaimable reports whether the entry expired before WithStaleGrace period, so it may be removed,
+// lock must be held
+func (c *Cache[K, V]) reclaimable(val *cached[K, V], now time.Time) bool {
+	return val.expired(now.Add(-c.staleGrace))
+}
@@ -0,0 +1,121 @@
+package lru
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time snapshot of the cache statistics
+type Stats struct {
+	// Hits and Misses count Get lookups, expired entries are counted as misses
+	Hits   uint64
+	Misses uint64
+	// Evictions counts entries evicted due to capacity
+	Evictions uint64
+	// Expirations counts expired entries removed from the cache
+	Expirations uint64
+
+	// Size is the number of live entries, see Len
+	Size     int
+	Capacity int
+	// EstimatedBytes is the sum of entries' sizes estimated by WithSizer, it's as accurate as the sizer is.
+	// Expired but not yet removed entries are counted
+	EstimatedBytes int64
+}
+
+// cacheLineSize is the assumed CPU cache line size
+const cacheLineSize = 64
+
+// paddedCounter is a counter taking a whole cache line, so counters updated by concurrent lookups
+// don't invalidate each other's cache lines (false sharing)
+type paddedCounter struct {
+	atomic.Uint64
+	_ [cacheLineSize - 8]byte
+}
+
+// stats counters are updated atomically outside the cache lock and without allocations,
+// so counting hits doesn't serialize lookups under the read lock
+type stats struct {
+	// the leading pad keeps hits off the cache line of the preceding Cache fields read by lookups
+	_           [cacheLineSize]byte
+	hits        paddedCounter
+	misses      paddedCounter
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+	// bytes is a gauge, so it isn't reset by ResetStats
+	bytes atomic.Int64
+}
+
+// Stats returns the cache statistics
+func (c *Cache[K, V]) Stats() Stats {
+	size := c.Len()
+	capacity := c.Cap()
+
+	return Stats{
+		Hits:        c.stats.hits.Load(),
+		Misses:      c.stats.misses.Load(),
+		Evictions:   c.stats.evictions.Load(),
+		Expirations: c.stats.expirations.Load(),
+		Size:        size,
+		Capacity:    capacity,
+
+		EstimatedBytes: c.stats.bytes.Load(),
+	}
+}
+
+// hit records a lookup hit
+func (c *Cache[K, V]) hit() {
+	c.stats.hits.Add(1)
+	c.metrics.IncHit()
+}
+
+// miss records a lookup miss
+func (c *Cache[K, V]) miss() {
+	c.stats.misses.Add(1)
+	c.metrics.IncMiss()
+}
+
+// ResetStats zeroes hits, misses, evictions and expirations counters keeping the cache entries,
+// e.g. to measure hit rate over intervals. Counters are reset one by one, so lookups running
+// concurrently with ResetStats may be counted in some of them only
+func (c *Cache[K, V]) ResetStats() {
+	c.stats.hits.Store(0)
+	c.stats.misses.Store(0)
+	c.stats.evictions.Store(0)
+	c.stats.expirations.Store(0)
+}
+
+// sizeOf returns the entry's size estimated by WithSizer, zero for cached misses or if the sizer panics,
+// lock must be held
+func (c *Cache[K, V]) sizeOf(val *cached[K, V]) (size int64) {
+	if c.sizer == nil || val.negative {
+		return 0
+	}
+	c.callback(func() {
+		size = c.sizer(val.key, val.value)
+	})
+	return size
+}
+
+// AgeHistogram counts non-expired entries by their age, the time since they were set, into buckets bounded
+// by ascending boundaries: counts[i] is the number of entries younger than buckets[i] but not younger than
+// buckets[i-1], the last count is the number of entries not younger than the last boundary.
+// It's a diagnostic taking O(n) in the number of entries holding the read lock
+func (c *Cache[K, V]) AgeHistogram(buckets []time.Duration) []int {
+	now := c.now()
+	counts := make([]int, len(buckets)+1)
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for e := c.evictList.Front(); e != nil; e = e.Next() {
+		val := c.valueOf(e)
+		if val.negative || val.expired(now) {
+			continue
+		}
+		age := now.Sub(val.createdAt)
+		counts[sort.Search(len(buckets), func(i int) bool { return age < buckets[i] })]++
+	}
+	return counts
+}
@@ -0,0 +1,36 @@
+package lru
+
+import "testing"
+
+// BenchmarkStats compares parallel Get hits with updating the hit counters alone,
+// the counters don't allocate and take a small share of Get
+func BenchmarkStats(b *testing.B) {
+	const keys = 1024
+
+	c, err := New[int, int](WithCapacity(keys))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := range keys {
+		c.Set(i, i)
+	}
+
+	b.Run("Get", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				c.Get(i % keys)
+				i++
+			}
+		})
+	})
+	b.Run("Counters", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.hit()
+			}
+		})
+	})
+}
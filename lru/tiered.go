@@ -0,0 +1,93 @@
+package lru
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Loader is a secondary storage backing the TieredCache, e.g. Redis or disk
+type Loader[K comparable, V any] interface {
+	// Load returns the key's value, ok = false if it isn't presented
+	Load(k K) (v V, ok bool)
+	// Store saves the value demoted from the in-memory cache
+	Store(k K, v V)
+}
+
+// errNotLoaded is returned by the compute function of TieredCache.Get if L2 has no value
+var errNotLoaded = errors.New("lru: value not loaded")
+
+// TieredCache is a two-tier cache using Cache as the hot L1 tier and Loader as the L2 tier,
+// L1 misses are loaded from L2 and promoted into L1
+type TieredCache[K comparable, V any] struct {
+	l1 *Cache[K, V]
+	l2 Loader[K, V]
+}
+
+// NewTiered creates a tiered cache with L1 configured by the options,
+// entries evicted from L1 due to capacity are stored to L2 if WithDemotion is provided
+func NewTiered[K comparable, V any](l2 Loader[K, V], opts ...Option) (*TieredCache[K, V], error) {
+	if l2 == nil {
+		return nil, fmt.Errorf("%w: L2 loader is nil", ErrInvalidOption)
+	}
+
+	o := applyOptions(opts)
+
+	if o.demotion {
+		onEvict, err := typedOption[func(K, V, EvictReason)](o.onEvict, "on evict callback")
+		if err != nil {
+			return nil, err
+		}
+
+		o.onEvict = func(k K, v V, reason EvictReason) {
+			if reason == ReasonCapacity {
+				l2.Store(k, v)
+			}
+			if onEvict != nil {
+				onEvict(k, v, reason)
+			}
+		}
+	}
+
+	l1, err := newCache[K, V](o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TieredCache[K, V]{l1: l1, l2: l2}, nil
+}
+
+// Set sets a value for specified key to L1
+func (t *TieredCache[K, V]) Set(k K, v V) {
+	t.l1.Set(k, v)
+}
+
+// Get looks up a key's value from L1, on miss loads it from L2 and sets to L1,
+// concurrent misses of the same key load it once
+func (t *TieredCache[K, V]) Get(k K) (value V, presented bool) {
+	v, err := t.l1.GetOrCompute(k, func() (V, error) {
+		v, ok := t.l2.Load(k)
+		if !ok {
+			return v, errNotLoaded
+		}
+		return v, nil
+	})
+	if err != nil {
+		return value, false
+	}
+	return v, true
+}
+
+// Delete removes the key's entry from L1, L2 isn't modified
+func (t *TieredCache[K, V]) Delete(k K) bool {
+	return t.l1.Delete(k)
+}
+
+// L1 returns the in-memory tier
+func (t *TieredCache[K, V]) L1() *Cache[K, V] {
+	return t.l1
+}
+
+// Close closes L1, see Cache.Close
+func (t *TieredCache[K, V]) Close() error {
+	return t.l1.Close()
+}